@@ -0,0 +1,710 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// Report contains the results of an Analyze call, in dataset order.
+type Report struct {
+	Results []Result
+}
+
+// Result describes the outcome of running a sorting function on one dataset.
+// Alg names the sorting function, and is only set by Compare. Len is the
+// length of the generated data, and Seed is the seed of the source passed to
+// the dataset's Generator. ID may be passed to ParseDataset to reproduce the
+// data. Runs is the number of ascending runs in the input; see Runs. Ratio is
+// the number of Less calls per n log2 n, and Warning is set when it exceeds
+// the Blowup factor. Calls, Agg, and Elems hold the statistics of the run, as
+// recorded by Stat's N, Aggregate, and O. Duration and Allocs are the wall
+// time and heap allocations of the first, uninstrumented run; NsPerOp is only
+// set by the Timing option. Trace contains the logged calls of the run, and
+// is only populated when the data was not correctly sorted. Err describes why
+// the run failed, and is empty if OK is true.
+type Result struct {
+	Alg      string
+	Name     string
+	ID       string
+	Len      int
+	Seed     int64
+	Runs     int
+	OK       bool
+	Ratio    float64
+	Warning  string
+	Calls    Counts
+	Agg      StatAggregate
+	Elems    []struct{ Less, Swap int64 }
+	Duration time.Duration
+	Allocs   uint64
+	NsPerOp  int64
+	Err      string
+	Trace    string
+}
+
+// stats summarizes the call counts of v in the style of (*Stat).String.
+func (v Result) stats() string {
+	return fmt.Sprintf("Calls: %+v\nLess:  %+v\nSwap:  %+v", v.Calls, v.Agg[0], v.Agg[1])
+}
+
+// title returns the name of v, qualified by the name of its algorithm if any.
+func (v Result) title() string {
+	if v.Alg == "" {
+		return v.Name
+	}
+	return v.Alg + "/" + v.Name
+}
+
+// Failed reports whether any dataset was not correctly sorted.
+func (r Report) Failed() bool {
+	for _, v := range r.Results {
+		if !v.OK {
+			return true
+		}
+	}
+	return false
+}
+
+// Generator returns a new dataset of length n, drawing any randomness it
+// needs from r. Generators should return the same data when given the same n
+// and an identically seeded r.
+type Generator func(n int, r *rand.Rand) sort.Interface
+
+// Case is a named dataset run by Analyze.
+type Case struct {
+	Name string
+	Gen  Generator
+}
+
+// Option configures a call to Analyze.
+type Option func(*config)
+
+type config struct {
+	sizes        []int
+	seed         int64
+	stable       bool
+	inplace      bool
+	warmup, reps int
+	ops          int
+	wall         time.Duration
+	golden       string
+	update       bool
+	blowup       float64
+	level        Level
+	leveled      bool
+}
+
+// DefaultLen is the dataset length used by Analyze when no sizes are given.
+const DefaultLen = 26
+
+// newConfig applies opts to the default configuration.
+func newConfig(opts []Option) *config {
+	c := &config{sizes: []int{DefaultLen}, seed: 1, blowup: DefaultBlowup}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Sizes causes each dataset to be generated and run once for each length in
+// n, rather than only at DefaultLen.
+func Sizes(n ...int) Option {
+	return func(c *config) { c.sizes = n }
+}
+
+// Seed sets the seed of the source passed to each Generator. The default seed
+// is 1. Any dataset may be reproduced by passing its Len and a source seeded
+// with its Seed to its Generator.
+func Seed(seed int64) Option {
+	return func(c *config) { c.seed = seed }
+}
+
+// Stable declares that the sorting function is stable, causing Analyze to also
+// fail any run in which equal elements do not retain their original order.
+func Stable() Option {
+	return func(c *config) { c.stable = true }
+}
+
+// Verbosity sets the amount of detail Analyze writes, overriding its verbose
+// argument, which otherwise selects Verbose if true, or Failures if false.
+// Below Failures, the calls made during failed runs are still recorded in
+// each Result's Trace.
+func Verbosity(l Level) Option {
+	return func(c *config) { c.level, c.leveled = l, true }
+}
+
+// InPlace declares that the sorting function does not allocate, causing
+// Analyze to also fail any run that makes heap allocations. Allocations are
+// counted process-wide, so other goroutines should be idle during Analyze.
+func InPlace() Option {
+	return func(c *config) { c.inplace = true }
+}
+
+// DefaultBlowup is the ratio of Less calls to n log2 n above which Analyze
+// warns of a complexity blowup, unless overridden with Blowup.
+const DefaultBlowup = 4.0
+
+// Blowup causes Analyze to warn when the Less calls made on a dataset of
+// length n exceed factor times n log2 n, which flags accidentally quadratic
+// algorithms even when they sort correctly. The warning does not fail the run.
+// A non-positive factor disables the warning.
+func Blowup(factor float64) Option {
+	return func(c *config) { c.blowup = factor }
+}
+
+// Timing causes Analyze to measure the mean wall time of f on each dataset,
+// reported as ns/op alongside the call counts. Each dataset is freshly
+// generated and sorted warmup times without measurement, then reps times with
+// measurement. Only the execution of f is timed.
+func Timing(warmup, reps int) Option {
+	return func(c *config) { c.warmup, c.reps = warmup, reps }
+}
+
+// Limit caps each run of the sorting function at ops Less and Swap calls in
+// total, and at a wall time of d, failing the run with a "LIMIT EXCEEDED"
+// error once either is exceeded. A non-positive value imposes no limit. This
+// prevents accidentally quadratic or non-terminating algorithms from stalling
+// the analysis. See Budget for caveats.
+func Limit(ops int, d time.Duration) Option {
+	return func(c *config) { c.ops, c.wall = ops, d }
+}
+
+// Golden enables golden-trace regression testing. The calls made by the
+// sorting function on each dataset are recorded, and compared against a golden
+// file in dir named after the dataset. If they differ, the run fails with a
+// description of the first divergent call. If the golden file does not exist,
+// or if update is true, the recorded calls are written to it instead.
+func Golden(dir string, update bool) Option {
+	return func(c *config) { c.golden, c.update = dir, update }
+}
+
+// goldenPath returns the path of the golden file for the named dataset.
+func (c *config) goldenPath(name string) string {
+	return filepath.Join(c.golden, strings.Map(func(r rune) rune {
+		if r == ' ' || r == '/' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)+".golden")
+}
+
+// checkGolden compares t against the golden file for the named dataset, or
+// writes it, as described by Golden.
+func (c *config) checkGolden(name string, t Trace) string {
+	path := c.goldenPath(name)
+	if !c.update {
+		f, err := os.Open(path)
+		if err == nil {
+			defer f.Close()
+			want, err := ReadTrace(f)
+			if err != nil {
+				return "golden: " + err.Error()
+			}
+			if d := t.Diff(want); d != "" {
+				return "trace diverges from golden: " + d
+			}
+			return ""
+		} else if !os.IsNotExist(err) {
+			return "golden: " + err.Error()
+		}
+	}
+	f, err := os.Create(path)
+	if err == nil {
+		_, err = t.WriteTo(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return "golden: " + err.Error()
+	}
+	return ""
+}
+
+// limit wraps data in a Budget if any limits are configured.
+func (c *config) limit(data sort.Interface) sort.Interface {
+	if c.ops <= 0 && c.wall <= 0 {
+		return data
+	}
+	b := &Budget{I: data, N: c.ops}
+	if c.ops <= 0 {
+		b.N = int(^uint(0) >> 1)
+	}
+	if c.wall > 0 {
+		b.Deadline = time.Now().Add(c.wall)
+	}
+	return b
+}
+
+var cases = []Case{
+	{"Shuffle", func(n int, r *rand.Rand) sort.Interface { return seqOf(r.Perm(n)) }},
+	{"Ascending", pattern(func(i, n int) int { return i })},
+	{"Descending", pattern(func(i, n int) int { return n - i - 1 })},
+	{"Pair-Transposition", pattern(transpose(func(i, n int) int { return i }))},
+	{"Zig-Zag", pattern(zigzag)},
+	{"Desc-Zag-Trans", pattern(transpose(zigzag))},
+	{"Shuffle Prime", func(n int, r *rand.Rand) sort.Interface {
+		for n > 2 && !big.NewInt(int64(n)).ProbablyPrime(0) {
+			n--
+		}
+		return seqOf(r.Perm(n))
+	}},
+	{"Duplicates", func(n int, r *rand.Rand) sort.Interface { return seqOf(randValues(n, 1+n/8, r)) }},
+	{"Two-Values", func(n int, r *rand.Rand) sort.Interface { return seqOf(randValues(n, 2, r)) }},
+	{"All-Equal", pattern(func(i, n int) int { return 0 })},
+}
+
+// randValues returns n values chosen uniformly from [0,k).
+func randValues(n, k int, r *rand.Rand) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = r.Intn(k)
+	}
+	return p
+}
+
+// seqOf returns a container holding the values of p, which should be in the
+// range [0,len(p)). Short sequences are returned as Letters for readability.
+func seqOf(p []int) sort.Interface {
+	if len(p) > 'z'-'a'+1 {
+		return sort.IntSlice(p)
+	}
+	l := make(Letters, len(p))
+	for i, v := range p {
+		l[i] = 'a' + byte(v)
+	}
+	return l
+}
+
+// pattern returns a Generator of the permutation f, where f(i, n) is the
+// value at index i of a sequence of length n.
+func pattern(f func(i, n int) int) Generator {
+	return func(n int, _ *rand.Rand) sort.Interface {
+		p := make([]int, n)
+		for i := range p {
+			p[i] = f(i, n)
+		}
+		return seqOf(p)
+	}
+}
+
+// transpose exchanges each pair of adjacent values of f. For odd n, the last
+// value is left in place.
+func transpose(f func(i, n int) int) func(i, n int) int {
+	return func(i, n int) int {
+		if i^1 < n {
+			i ^= 1
+		}
+		return f(i, n)
+	}
+}
+
+// zigzag interleaves the ascending even values with the descending odd values.
+func zigzag(i, n int) int {
+	if i%2 == 0 {
+		return i
+	}
+	m := n - 1
+	if m%2 == 0 {
+		m--
+	}
+	return m - i + 1
+}
+
+// RegisterCase adds a named dataset to those run by every subsequent call to
+// Analyze, after the built-in datasets. Since the registry is not
+// synchronized, RegisterCase should be called during program initialization.
+func RegisterCase(name string, gen Generator) {
+	cases = append(cases, Case{name, gen})
+}
+
+// Analyze runs preselected datasets through the sorting function f.
+// Any runs that fail to be correctly sorted will be listed first. For each
+// run, if verbose is true or a run fails its Len, Less, and Swap calls will
+// be logged to the provided Writer. In all cases, a summary of call count
+// statistics will be written to the Writer. The Verbosity option selects
+// more or less output than this. The returned Report contains the same
+// information in structured form.
+//
+// Additional datasets may be added with RegisterCase. By default, each
+// dataset is generated once, with a length of DefaultLen, from a source with
+// a fixed seed; see Sizes and Seed. If the Stable option is given, each
+// dataset is proxied by its original indices in order to check stability.
+// Runs whose output is not a permutation of their input also fail, for
+// datasets that support comparison with IsPermutationOf.
+// A panic raised by f fails the run in which it occurred, and does not prevent
+// the remaining datasets from being run. See Golden for detecting changes in
+// the behavior, rather than only the correctness, of f.
+func Analyze(w io.Writer, verbose bool, f func(sort.Interface), opts ...Option) Report {
+	c := newConfig(opts)
+	if !c.leveled {
+		c.level = Failures
+		if verbose {
+			c.level = Verbose
+		}
+	}
+	if c.level <= Silent {
+		w = io.Discard
+	}
+	type test struct {
+		Case
+		n int
+	}
+	var tests []test
+	for _, v := range cases {
+		for _, n := range c.sizes {
+			tests = append(tests, test{v, n})
+		}
+	}
+	// gen returns the dataset to be passed to f, along with the generated
+	// data and the original indices to be checked after sorting.
+	gen := func(i int) (sort.Interface, sort.Interface, []int) {
+		data := tests[i].Gen(tests[i].n, rand.New(rand.NewSource(c.seed)))
+		if !c.stable {
+			return data, data, nil
+		}
+		idx := NewIntSeq(data.Len())
+		return NewProxy(data, idx), data, idx
+	}
+	n := len(tests)
+	results := make([]Result, n)
+	succ := make([]int, 0, n*2)
+	succ, fail := succ[:0], succ[n:n]
+	tlen := 0
+	// Sort failures first
+	for i, v := range tests {
+		x, data, idx := gen(i)
+		title := v.Name
+		if len(c.sizes) > 1 {
+			title = fmt.Sprintf("%s n=%d", v.Name, v.n)
+		}
+		if len(title) > tlen {
+			tlen = len(title)
+		}
+		results[i].Name, results[i].Len, results[i].Seed = title, data.Len(), c.seed
+		results[i].ID = Dataset{v.Name, v.n, c.seed}.String()
+		results[i].Runs = len(Runs(data))
+		x = c.limit(x)
+		m := mallocs()
+		t := time.Now()
+		err := protect(f, x)
+		results[i].Duration = time.Since(t)
+		results[i].Allocs = mallocs() - m
+		if err == "" {
+			err = check(data, idx)
+		}
+		if err == "" {
+			err = checkPerm(data, tests[i].Gen(tests[i].n, rand.New(rand.NewSource(c.seed))))
+		}
+		if err == "" && c.inplace && results[i].Allocs > 0 {
+			err = fmt.Sprintf("made %d allocations", results[i].Allocs)
+		}
+		if c.reps > 0 && err == "" {
+			results[i].NsPerOp, err = c.bench(c.reps, f, func() sort.Interface {
+				x, _, _ := gen(i)
+				return x
+			})
+			if err != "" {
+				err = "timing: " + err
+			}
+		}
+		if err == "" {
+			succ = append(succ, i)
+			results[i].OK = true
+		} else {
+			fail = append(fail, i)
+			results[i].Err = err
+		}
+	}
+	if c.golden != "" {
+		// Golden failures are found by recording a separate run, and must
+		// be known before failures can be listed first.
+		for i := 0; i < len(succ); i++ {
+			j := succ[i]
+			x, _, _ := gen(j)
+			rec := &Recorder{I: x}
+			protect(f, c.limit(rec))
+			if err := c.checkGolden(results[j].Name, rec.T); err != "" {
+				results[j].OK, results[j].Err = false, err
+				fail = append(fail, j)
+				succ = append(succ[:i], succ[i+1:]...)
+				i--
+			}
+		}
+	}
+	n = len(fail)
+	pad := 4 + 7 + 4
+	banner := strings.Repeat("#", tlen+pad)
+	for i, j := range append(fail, succ...) {
+		x, _, _ := gen(j)
+		title := results[j].Name
+		status := "[ OK ]"
+		stat := NewStat(x)
+		var trace bytes.Buffer
+		var lg *Log
+		switch {
+		case i < n:
+			status = "[FAIL]"
+			lw := io.Writer(&trace)
+			if c.level >= Failures {
+				lw = io.MultiWriter(w, &trace)
+			}
+			lg = &Log{I: x, W: lw, Level: max(c.level, Verbose), Buffered: true}
+		case c.level >= Verbose:
+			lg = &Log{I: x, W: w, Level: c.level, Buffered: true}
+		}
+		if lg != nil {
+			stat.I = lg
+		}
+		fmt.Fprintf(w, "%s\n### %s %-*s ###\n%s\n", banner, status, tlen, title, banner)
+		protect(f, c.limit(stat))
+		if lg != nil {
+			lg.Flush()
+		}
+		fmt.Fprint(w, "\n", stat, "\n")
+		if c.reps > 0 {
+			fmt.Fprintf(w, "Time:  %d ns/op\n", results[j].NsPerOp)
+		}
+		if a := results[j].Allocs; a > 0 {
+			fmt.Fprintf(w, "Alloc: %d\n", a)
+		}
+		fmt.Fprint(w, "\n")
+		if n := float64(results[j].Len); n > 1 {
+			r := &results[j]
+			r.Ratio = float64(stat.N.Less) / (n * math.Log2(n))
+			if c.blowup > 0 && r.Ratio > c.blowup {
+				r.Warning = fmt.Sprintf("%d Less calls is %.1f times n log2 n", stat.N.Less, r.Ratio)
+				fmt.Fprintf(w, "Warning: %s\n\n", r.Warning)
+			}
+		}
+		if err := results[j].Err; err != "" {
+			fmt.Fprintf(w, "Error: %s (dataset %s)\n\n", err, results[j].ID)
+		}
+		r := &results[j]
+		r.Calls, r.Agg, r.Elems, r.Trace = stat.N, stat.Aggregate(), stat.O, trace.String()
+	}
+	return Report{results}
+}
+
+// checkPerm returns a description of the defect if data is not a permutation
+// of orig, which is regenerated input. Containers that do not support
+// comparison against one another are not checked.
+func checkPerm(data, orig sort.Interface) string {
+	ab, ba := crossLess(data, orig), crossLess(orig, data)
+	if ab == nil || ba == nil || isPermutation(data, orig, ab, ba) {
+		return ""
+	}
+	return "output is not a permutation of input"
+}
+
+// mallocs returns the cumulative number of heap objects allocated.
+func mallocs() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Mallocs
+}
+
+// protect calls f(data), returning a description of any panic raised by f.
+func protect(f func(sort.Interface), data sort.Interface) (err string) {
+	defer func() {
+		switch v := recover(); v {
+		case nil:
+		case ErrBudget, ErrDeadline:
+			err = fmt.Sprint("LIMIT EXCEEDED: ", v)
+		default:
+			err = fmt.Sprint("panic: ", v)
+		}
+	}()
+	f(data)
+	return ""
+}
+
+// check returns a description of the first defect in data, or the empty
+// string if data is sorted. If idx is non-nil, it holds the original indices
+// of the elements in data, and equal elements must be in their original order.
+func check(data sort.Interface, idx []int) string {
+	for i, n := 1, data.Len(); i < n; i++ {
+		if data.Less(i, i-1) {
+			return fmt.Sprintf("unsorted at index %d", i)
+		}
+		if idx != nil && idx[i] < idx[i-1] && !data.Less(i-1, i) {
+			return fmt.Sprintf("unstable at index %d", i)
+		}
+	}
+	return ""
+}
+
+// bench returns the mean time in nanoseconds of f over reps runs, each on a
+// newly generated dataset, following c.warmup unmeasured runs. Like the
+// checked run, each run is protected and subject to c's limits; bench stops
+// at the first that fails, returning a description of the failure.
+func (c *config) bench(reps int, f func(sort.Interface), gen func() sort.Interface) (int64, string) {
+	for i := 0; i < c.warmup; i++ {
+		if err := protect(f, c.limit(gen())); err != "" {
+			return 0, err
+		}
+	}
+	var d time.Duration
+	for i := 0; i < reps; i++ {
+		data := c.limit(gen())
+		t := time.Now()
+		err := protect(f, data)
+		d += time.Since(t)
+		if err != "" {
+			return 0, err
+		}
+	}
+	return int64(d) / int64(reps), ""
+}
+
+// AnalyzeT runs the same datasets as Analyze through f, reporting each as a
+// subtest of t named after the dataset. Call count statistics are logged for
+// every dataset, and datasets that are not correctly sorted fail their subtest
+// with an error and the logged calls. If t is not a *testing.T, the datasets
+// are reported directly to t instead of as subtests.
+func AnalyzeT(t testing.TB, f func(sort.Interface), opts ...Option) {
+	t.Helper()
+	r := Analyze(io.Discard, false, f, opts...)
+	for _, v := range r.Results {
+		v := v
+		report := func(t testing.TB) {
+			t.Helper()
+			t.Logf("%s: %s", v.Name, v.stats())
+			if !v.OK {
+				t.Errorf("%s: %s\n%s", v.Name, v.Err, v.Trace)
+			}
+		}
+		if tt, ok := t.(*testing.T); ok {
+			tt.Run(v.Name, func(t *testing.T) { report(t) })
+		} else {
+			report(t)
+		}
+	}
+}
+
+// Bench benchmarks sortFn on inputs from gen at each of the given sizes, as
+// sub-benchmarks of b named like "n=1000". If gen is nil, every dataset used
+// by Analyze is benchmarked, as sub-benchmarks named like "Shuffle/n=1000".
+// A fresh input is generated for each iteration, with the timer stopped, from
+// a source seeded with the iteration number. Comparisons and swaps per sort
+// are reported alongside the time, as are allocations, which exclude those
+// made while generating inputs.
+func Bench(b *testing.B, sortFn func(sort.Interface), gen Generator, sizes []int) {
+	run := func(b *testing.B, gen Generator) {
+		for _, n := range sizes {
+			b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+				b.ReportAllocs()
+				var calls Counts
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					c := NewCount(gen(n, rand.New(rand.NewSource(int64(i)))))
+					b.StartTimer()
+					sortFn(c)
+					calls.Less += c.N.Less
+					calls.Swap += c.N.Swap
+				}
+				b.ReportMetric(float64(calls.Less)/float64(b.N), "less/op")
+				b.ReportMetric(float64(calls.Swap)/float64(b.N), "swap/op")
+			})
+		}
+	}
+	if gen != nil {
+		run(b, gen)
+		return
+	}
+	for _, v := range cases {
+		gen := v.Gen
+		b.Run(v.Name, func(b *testing.B) { run(b, gen) })
+	}
+}
+
+// Compare runs every dataset through each of the named sorting functions in
+// algs, as Analyze would, and writes a matrix of Less calls, Swap calls, and
+// time per algorithm and dataset to w. The lowest value in each column is
+// marked with an asterisk; failed runs are marked with an exclamation point
+// and are never considered the lowest. The time is the ns/op measured by the
+// Timing option if given, or else the duration of a single run. The returned
+// Report contains the results of every algorithm, ordered by algorithm name.
+func Compare(w io.Writer, algs map[string]func(sort.Interface), opts ...Option) Report {
+	names := make([]string, 0, len(algs))
+	for k := range algs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var report Report
+	rows := make([][]Result, len(names))
+	for i, name := range names {
+		r := Analyze(io.Discard, false, algs[name], opts...)
+		for j := range r.Results {
+			r.Results[j].Alg = name
+		}
+		rows[i] = r.Results
+		report.Results = append(report.Results, r.Results...)
+	}
+	if len(rows) == 0 {
+		return report
+	}
+	metrics := []struct {
+		title string
+		f     func(Result) int64
+	}{
+		{"Less", func(r Result) int64 { return r.Calls.Less }},
+		{"Swap", func(r Result) int64 { return r.Calls.Swap }},
+		{"ns/op", func(r Result) int64 {
+			if r.NsPerOp > 0 {
+				return r.NsPerOp
+			}
+			return int64(r.Duration)
+		}},
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for _, m := range metrics {
+		fmt.Fprintf(tw, "%s\t", m.title)
+		for _, v := range rows[0] {
+			fmt.Fprintf(tw, "%s\t", v.Name)
+		}
+		fmt.Fprint(tw, "\n")
+		best := make([]int64, len(rows[0]))
+		for j := range best {
+			best[j] = -1
+			for _, row := range rows {
+				if v := m.f(row[j]); row[j].OK && (best[j] < 0 || v < best[j]) {
+					best[j] = v
+				}
+			}
+		}
+		for i, row := range rows {
+			fmt.Fprintf(tw, "%s\t", names[i])
+			for j, r := range row {
+				v, mark := m.f(r), " "
+				switch {
+				case !r.OK:
+					mark = "!"
+				case v == best[j]:
+					mark = "*"
+				}
+				fmt.Fprintf(tw, "%d%s\t", v, mark)
+			}
+			fmt.Fprint(tw, "\n")
+		}
+		fmt.Fprint(tw, "\n")
+	}
+	tw.Flush()
+	return report
+}
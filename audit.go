@@ -0,0 +1,111 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Snapshotter is implemented by containers that can fingerprint their
+// elements. Snapshot returns one value per element, such that equal elements
+// have equal fingerprints and unequal elements usually do not.
+type Snapshotter interface {
+	Snapshot() []uint64
+}
+
+// Snapshotted is a sort.Interface that also implements Snapshotter.
+type Snapshotted interface {
+	sort.Interface
+	Snapshotter
+}
+
+// Audit wraps a Snapshotter-capable container, verifying that it is modified
+// only through Swap. It records a snapshot of the container, mirrors every
+// Swap onto the snapshot, and compares the snapshot with the container when
+// Verify is called, or after every call if Every is true, in which case any
+// violation panics with an *AuditError. This catches sorting functions that
+// write through a captured slice, or that lose or duplicate elements.
+type Audit struct {
+	I     Snapshotted
+	Every bool
+	want  []uint64
+	n     int
+}
+
+// NewAudit returns an Audit of data, taking its initial snapshot.
+func NewAudit(data Snapshotted) *Audit {
+	return &Audit{I: data, want: data.Snapshot()}
+}
+
+// AuditError describes a modification detected by an Audit.
+type AuditError struct {
+	Calls int  // calls made through the Audit before detection
+	Index int  // first index whose element was unexpected
+	Lost  bool // whether the multiset of elements changed
+}
+
+func (e *AuditError) Error() string {
+	what := "modified outside Swap"
+	if e.Lost {
+		what = "changed the multiset of elements"
+	}
+	return fmt.Sprintf("sortutil: after %d calls, index %d %s", e.Calls, e.Index, what)
+}
+
+func (a *Audit) Len() int { a.check(); return a.I.Len() }
+
+func (a *Audit) Less(i, j int) bool {
+	r := a.I.Less(i, j)
+	a.check()
+	return r
+}
+
+func (a *Audit) Swap(i, j int) {
+	a.I.Swap(i, j)
+	a.want[i], a.want[j] = a.want[j], a.want[i]
+	a.check()
+}
+
+func (a *Audit) check() {
+	a.n++
+	if !a.Every {
+		return
+	}
+	if err := a.Verify(); err != nil {
+		panic(err)
+	}
+}
+
+// Verify compares the container against the expected snapshot, returning an
+// *AuditError describing the first discrepancy, or nil.
+func (a *Audit) Verify() error {
+	got := a.I.Snapshot()
+	for i := range got {
+		if i >= len(a.want) || got[i] != a.want[i] {
+			return &AuditError{a.n, i, !sameMultiset(got, a.want)}
+		}
+	}
+	if len(got) != len(a.want) {
+		return &AuditError{a.n, len(got), true}
+	}
+	return nil
+}
+
+func sameMultiset(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[uint64]int, len(a))
+	for _, v := range a {
+		m[v]++
+	}
+	for _, v := range b {
+		if m[v]--; m[v] < 0 {
+			return false
+		}
+	}
+	return true
+}
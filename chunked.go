@@ -0,0 +1,249 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// DefaultChunkSize is the chunk size used by a Chunked with a zero Size.
+const DefaultChunkSize = 4096
+
+// Chunked sorts large data by dividing it into chunks of Size elements,
+// sorting the chunks concurrently, then merging adjacent runs pairwise,
+// concurrently within each round, until one run remains. The result is
+// stable if the sorting function is stable.
+//
+// Less and Swap on data must be safe to call concurrently on disjoint
+// indices, as is true of slice-backed containers.
+//
+// By default, runs are merged in place using block rotations. If Buffered is
+// true and data implements both MoveInterface and ValueLesser, the left run of
+// each merge is copied into a buffer instead, which makes fewer calls at the
+// cost of memory.
+type Chunked struct {
+	Size     int  // elements per chunk; if <= 0, DefaultChunkSize is used
+	Workers  int  // concurrent goroutines; if <= 0, runtime.GOMAXPROCS(0) is used
+	Buffered bool // merge through a buffer where possible
+
+	Chunks []Counts // calls made while sorting each chunk, set by Sort
+	Merge  Counts   // calls made while merging, set by Sort
+}
+
+// Sort sorts data by calling sortFn on each chunk, then merging. If sortFn
+// or a method of data panics, Sort panics with the same value once all
+// running goroutines have finished.
+func (c *Chunked) Sort(data sort.Interface, sortFn func(sort.Interface)) {
+	size, workers := c.Size, c.Workers
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	n := data.Len()
+	c.Chunks = make([]Counts, (n+size-1)/size)
+	c.Merge = Counts{}
+	p := pool{sem: make(chan struct{}, workers)}
+	for i := range c.Chunks {
+		i, lo, hi := i, i*size, min((i+1)*size, n)
+		p.do(func() {
+			s := NewStat(NewSub(data, lo, hi))
+			sortFn(s)
+			c.Chunks[i] = s.N
+		})
+	}
+	p.wait()
+	_, ok1 := data.(MoveInterface)
+	v, ok2 := data.(ValueLesser)
+	buffered := c.Buffered && ok1 && ok2
+	for w := size; w < n; w *= 2 {
+		counts := make([]Counts, (n+2*w-1)/(2*w))
+		for k := range counts {
+			k, lo, mid, hi := k, 2*k*w, min(2*k*w+w, n), min(2*k*w+2*w, n)
+			if mid == hi {
+				continue
+			}
+			p.do(func() {
+				if buffered {
+					buf := &values{s: make([]interface{}, mid-lo+1), v: v}
+					MergeBuffered(data, buf, lo, mid, hi)
+					counts[k] = Counts{Less: buf.less}
+					return
+				}
+				s := NewStat(NewSub(data, lo, hi))
+				symMerge(s, 0, mid-lo, hi-lo)
+				counts[k] = s.N
+			})
+		}
+		p.wait()
+		for _, k := range counts {
+			c.Merge.Len += k.Len
+			c.Merge.Less += k.Less
+			c.Merge.Swap += k.Swap
+		}
+	}
+}
+
+// pool runs functions on a bounded number of goroutines, recording the first
+// panic so that it can be raised again by wait.
+type pool struct {
+	sem   chan struct{}
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	panic interface{}
+}
+
+func (p *pool) do(f func()) {
+	p.wg.Add(1)
+	p.sem <- struct{}{}
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				p.mu.Lock()
+				if p.panic == nil {
+					p.panic = e
+				}
+				p.mu.Unlock()
+			}
+			<-p.sem
+			p.wg.Done()
+		}()
+		f()
+	}()
+}
+
+func (p *pool) wait() {
+	p.wg.Wait()
+	if p.panic != nil {
+		panic(p.panic)
+	}
+}
+
+// values is a buffer of opaque values, as returned by MoveInterface.Load,
+// ordered by v. It lets Chunked merge through MergeBuffered without a
+// container of the type of the data, and counts its comparisons.
+type values struct {
+	s    []interface{}
+	v    ValueLesser
+	less int64
+}
+
+func (b *values) Len() int                     { return len(b.s) }
+func (b *values) Swap(i, j int)                { b.s[i], b.s[j] = b.s[j], b.s[i] }
+func (b *values) Move(dst, src int)            { b.s[dst] = b.s[src] }
+func (b *values) Load(i int) interface{}       { return b.s[i] }
+func (b *values) Store(dst int, v interface{}) { b.s[dst] = v }
+
+func (b *values) Less(i, j int) bool {
+	b.less++
+	return b.v.LessValue(b.s[i], b.s[j])
+}
+
+// symMerge stably merges the sorted runs [a,m) and [m,b) of data in place,
+// using the SymMerge algorithm of Kim and Kutzner, as in package sort.
+func symMerge(data sort.Interface, a, m, b int) {
+	if m-a == 1 {
+		i, j := m, b
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if data.Less(h, a) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := a; k < i-1; k++ {
+			data.Swap(k, k+1)
+		}
+		return
+	}
+	if b-m == 1 {
+		i, j := a, m
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if !data.Less(m, h) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := m; k > i; k-- {
+			data.Swap(k, k-1)
+		}
+		return
+	}
+	mid := int(uint(a+b) >> 1)
+	n := mid + m
+	var start, r int
+	if m > mid {
+		start, r = n-b, mid
+	} else {
+		start, r = a, m
+	}
+	p := n - 1
+	for start < r {
+		c := int(uint(start+r) >> 1)
+		if !data.Less(p-c, c) {
+			start = c + 1
+		} else {
+			r = c
+		}
+	}
+	end := n - start
+	if start < m && m < end {
+		// exchange the blocks [start,m) and [m,end)
+		Skew(data, start, end-(m-start), m-start)
+	}
+	if a < start && start < mid {
+		symMerge(data, a, start, mid)
+	}
+	if mid < end && end < b {
+		symMerge(data, mid, end, b)
+	}
+}
+
+// MergeBuffered stably merges the sorted runs [lo,mid) and [mid,hi) of data.
+// If data and buf both implement MoveInterface, the left run is copied into
+// buf, which must hold elements of the same type as data and have a Len of at
+// least mid-lo+1, and elements are moved directly into place. Otherwise, the
+// runs are merged in place by block rotations, and buf is unused and may be
+// nil.
+func MergeBuffered(data, buf sort.Interface, lo, mid, hi int) {
+	d, ok1 := data.(MoveInterface)
+	b, ok2 := buf.(MoveInterface)
+	if !ok1 || !ok2 {
+		if lo < mid && mid < hi {
+			symMerge(data, lo, mid, hi)
+		}
+		return
+	}
+	n := mid - lo
+	if buf.Len() <= n {
+		panic(panicmsg)
+	}
+	for i := 0; i < n; i++ {
+		b.Store(i, d.Load(lo+i))
+	}
+	// buf[n] holds the head of the right run, so that it can be compared
+	// with the head of the left run by buf.Less.
+	i, j, k := 0, mid, lo
+	for i < n && j < hi {
+		b.Store(n, d.Load(j))
+		if buf.Less(n, i) {
+			d.Move(k, j)
+			j++
+		} else {
+			d.Store(k, b.Load(i))
+			i++
+		}
+		k++
+	}
+	for ; i < n; i, k = i+1, k+1 {
+		d.Store(k, b.Load(i))
+	}
+}
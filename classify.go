@@ -0,0 +1,120 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+// Family is a broad class of sorting algorithm, as guessed by Classify.
+type Family int
+
+const (
+	FamilyUnknown Family = iota
+	FamilyInsertion
+	FamilyBubble
+	FamilySelection
+	FamilyQuick
+	FamilyHeap
+	FamilyMerge
+)
+
+var familyNames = [...]string{
+	FamilyUnknown:   "unknown",
+	FamilyInsertion: "insertion",
+	FamilyBubble:    "bubble",
+	FamilySelection: "selection",
+	FamilyQuick:     "quick",
+	FamilyHeap:      "heap",
+	FamilyMerge:     "merge",
+}
+
+func (f Family) String() string { return familyNames[f] }
+
+// Classify guesses the family of the algorithm that produced t, from patterns
+// in its calls: heap sorts compare parents with children at indices 2i+1 and
+// 2i+2, insertion and bubble sorts touch adjacent elements while scanning
+// down and up respectively, and quick and selection sorts scan one index
+// against a fixed pivot or minimum, distinguished by how often they swap,
+// while merge sorts instead bisect against a fixed index. Hybrid algorithms
+// are classified by whichever pattern dominates.
+//
+// Classify is experimental: its heuristics are tuned on textbook
+// implementations, and its result should be taken as a hint.
+func Classify(t Trace) Family {
+	var ops, heap, adj, up, down, less, swaps, scan, bisect, n int
+	var prev Op
+	for _, o := range t {
+		if o.Kind == OpLen {
+			continue
+		}
+		ops++
+		lo, hi := o.I, o.J
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if hi >= n {
+			n = hi + 1
+		}
+		if hi == 2*lo+1 || hi == 2*lo+2 {
+			heap++
+		}
+		if hi-lo == 1 {
+			adj++
+		}
+		if o.Kind == OpSwap {
+			swaps++
+			continue
+		}
+		less++
+		if prev.Kind == OpLess {
+			switch lo - min(prev.I, prev.J) {
+			case 1:
+				up++
+			case -1:
+				down++
+			}
+			if shared, a, b := sharedIndex(prev, o); shared {
+				if d := a - b; d == 1 || d == -1 {
+					scan++
+				} else {
+					bisect++
+				}
+			}
+		}
+		prev = o
+	}
+	switch {
+	case ops == 0:
+		return FamilyUnknown
+	case 2*heap > ops:
+		return FamilyHeap
+	case 10*adj > 9*ops:
+		if up > down {
+			return FamilyBubble
+		}
+		return FamilyInsertion
+	case 2*scan > less:
+		if swaps < n {
+			return FamilySelection
+		}
+		return FamilyQuick
+	case 2*bisect > scan:
+		return FamilyMerge
+	}
+	return FamilyUnknown
+}
+
+// sharedIndex reports whether two comparisons share an index, and if so,
+// returns their other indices.
+func sharedIndex(p, q Op) (bool, int, int) {
+	switch {
+	case p.I == q.I:
+		return true, p.J, q.J
+	case p.J == q.J:
+		return true, p.I, q.I
+	case p.I == q.J:
+		return true, p.J, q.I
+	case p.J == q.I:
+		return true, p.I, q.J
+	}
+	return false, 0, 0
+}
@@ -0,0 +1,269 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command sortutil runs the datasets of sortutil.Analyze through sorting
+// algorithms that are not linked into the calling program.
+//
+// Usage:
+//
+//	sortutil [flags] alg...
+//
+// Each alg is either the path of a Go plugin, ending in ".so", that exports
+// a function
+//
+//	func Sort(data sort.Interface)
+//
+// or a command line, run once per dataset, that speaks the oracle protocol
+// on its standard input and output. The tool first sends the length of the
+// data, as in
+//
+//	Len 26
+//
+// after which the program sends one request per line: "Less i j", to which
+// the tool replies "true" or "false", or "Swap i j", which has no reply. The
+// program ends the sort by sending "Done" or exiting. The length line and
+// requests are those of a sortutil.Trace, except that a Less request omits
+// its result, which is instead the reply. Any language that can read and write
+// lines can thus be analyzed. With -timeout, a program that runs too long is
+// killed, whether or not it is waiting for a reply.
+//
+// With one alg, the output is that of Analyze; with several, that of Compare.
+// The -format flag writes the Report as CSV, HTML, JSON, JUnit XML, Markdown,
+// or TAP instead.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/extemporalgenome/sortutil"
+)
+
+var (
+	verbose = flag.Bool("v", false, "log the calls of every run")
+	sizes   = flag.String("sizes", "", "comma-separated dataset lengths")
+	seed    = flag.Int64("seed", 1, "seed of the dataset generators")
+	stable  = flag.Bool("stable", false, "check that the algorithms are stable")
+	reps    = flag.Int("reps", 0, "timed runs per dataset")
+	ops     = flag.Int("ops", 0, "maximum Less and Swap calls per run")
+	timeout = flag.Duration("timeout", 0, "maximum wall time per run")
+	format  = flag.String("format", "", "report format: csv, html, json, junit, markdown, or tap")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("sortutil: ")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sortutil [flags] alg...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts := []sortutil.Option{sortutil.Seed(*seed), sortutil.Limit(*ops, *timeout)}
+	if *sizes != "" {
+		var n []int
+		for _, s := range strings.Split(*sizes, ",") {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				log.Fatalf("invalid size %q", s)
+			}
+			n = append(n, v)
+		}
+		opts = append(opts, sortutil.Sizes(n...))
+	}
+	if *stable {
+		opts = append(opts, sortutil.Stable())
+	}
+	if *reps > 0 {
+		opts = append(opts, sortutil.Timing(1, *reps))
+	}
+	if err := checkNames(flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+	algs := make(map[string]func(sort.Interface))
+	var name string
+	for _, arg := range flag.Args() {
+		name = algName(arg)
+		if strings.HasSuffix(arg, ".so") {
+			algs[name] = load(arg)
+		} else if argv := strings.Fields(arg); len(argv) > 0 {
+			algs[name] = oracle(argv, *timeout)
+		}
+	}
+	out := io.Writer(os.Stdout)
+	if *format != "" {
+		out = io.Discard
+	}
+	var r sortutil.Report
+	if len(algs) == 1 {
+		r = sortutil.Analyze(out, *verbose, algs[name], opts...)
+	} else {
+		r = sortutil.Compare(out, algs, opts...)
+	}
+	if err := write(os.Stdout, r, *format); err != nil {
+		log.Fatal(err)
+	}
+	if r.Failed() {
+		os.Exit(1)
+	}
+}
+
+func write(w io.Writer, r sortutil.Report, format string) error {
+	switch format {
+	case "":
+		return nil
+	case "csv":
+		return r.WriteCSV(w)
+	case "html":
+		return r.WriteHTML(w, "sortutil")
+	case "json":
+		return r.WriteJSON(w)
+	case "junit":
+		return r.WriteJUnit(w, "sortutil")
+	case "markdown":
+		return r.WriteMarkdown(w)
+	case "tap":
+		return r.WriteTAP(w)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// checkNames returns an error if any alg in args has a blank name, or the
+// same name as another.
+func checkNames(args []string) error {
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		name := algName(arg)
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("empty algorithm name in %q", arg)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate algorithm name %q", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// algName returns the name under which arg is reported: the base name of a
+// plugin, without its extension, or the whole command line.
+func algName(arg string) string {
+	if strings.HasSuffix(arg, ".so") {
+		return strings.TrimSuffix(filepath.Base(arg), ".so")
+	}
+	return arg
+}
+
+// load returns the Sort function exported by the plugin at path.
+func load(path string) func(sort.Interface) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sym, err := p.Lookup("Sort")
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, ok := sym.(func(sort.Interface))
+	if !ok {
+		log.Fatalf("%s: Sort has type %T, want func(sort.Interface)", path, sym)
+	}
+	return f
+}
+
+// oracle returns a sorting function that runs argv, serving its requests
+// as described in the package documentation. Protocol errors and failures of
+// the program panic, which fails the run, as does the program running longer
+// than timeout, if positive.
+func oracle(argv []string, timeout time.Duration) func(sort.Interface) {
+	return func(data sort.Interface) {
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Stderr = os.Stderr
+		in, err := cmd.StdinPipe()
+		if err != nil {
+			panic(err)
+		}
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			panic(err)
+		}
+		if err := cmd.Start(); err != nil {
+			panic(err)
+		}
+		killed := make(chan struct{})
+		if timeout > 0 {
+			t := time.AfterFunc(timeout, func() {
+				close(killed)
+				cmd.Process.Kill()
+			})
+			defer t.Stop()
+		}
+		expired := func() {
+			select {
+			case <-killed:
+				panic(fmt.Sprintf("killed after %v", timeout))
+			default:
+			}
+		}
+		done := false
+		defer func() {
+			if !done {
+				in.Close()
+				cmd.Process.Kill()
+				cmd.Wait()
+			}
+		}()
+		w := bufio.NewWriter(in)
+		fmt.Fprintln(w, "Len", data.Len())
+		w.Flush()
+		s := bufio.NewScanner(out)
+		for s.Scan() {
+			f := strings.Fields(s.Text())
+			if len(f) == 1 && f[0] == "Done" {
+				break
+			}
+			if len(f) != 3 || f[0] != "Less" && f[0] != "Swap" {
+				panic(fmt.Sprintf("invalid request %q", s.Text()))
+			}
+			i, err1 := strconv.Atoi(f[1])
+			j, err2 := strconv.Atoi(f[2])
+			if err1 != nil || err2 != nil {
+				panic(fmt.Sprintf("invalid request %q", s.Text()))
+			}
+			if f[0] == "Swap" {
+				data.Swap(i, j)
+				continue
+			}
+			fmt.Fprintln(w, data.Less(i, j))
+			if err := w.Flush(); err != nil {
+				expired()
+				panic(err)
+			}
+		}
+		expired()
+		if err := s.Err(); err != nil {
+			panic(err)
+		}
+		done = true
+		in.Close()
+		if err := cmd.Wait(); err != nil {
+			expired()
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/extemporalgenome/sortutil"
+)
+
+// TestMain doubles as the program run by oracle, behaving as selected by
+// $SORTUTIL_ORACLE when the tests run the test binary again.
+func TestMain(m *testing.M) {
+	switch os.Getenv("SORTUTIL_ORACLE") {
+	case "":
+		os.Exit(m.Run())
+	case "insertion":
+		insertion()
+	case "hang":
+		time.Sleep(time.Minute)
+	case "bad":
+		fmt.Println("Shuffle 0 1")
+	}
+	os.Exit(0)
+}
+
+// insertion speaks the oracle protocol on behalf of an insertion sort.
+func insertion() {
+	s := bufio.NewScanner(os.Stdin)
+	var n int
+	if !s.Scan() {
+		os.Exit(1)
+	}
+	fmt.Sscanf(s.Text(), "Len %d", &n)
+	for i := 1; i < n; i++ {
+		for j := i; j > 0; j-- {
+			fmt.Println("Less", j, j-1)
+			if !s.Scan() || s.Text() != "true" {
+				break
+			}
+			fmt.Println("Swap", j, j-1)
+		}
+	}
+	fmt.Println("Done")
+}
+
+func TestOracle(t *testing.T) {
+	t.Setenv("SORTUTIL_ORACLE", "insertion")
+	f := oracle([]string{os.Args[0]}, 0)
+	data := sort.IntSlice{5, 2, 8, 1, 9, 3}
+	f(data)
+	if !sort.IsSorted(data) {
+		t.Errorf("got %v", data)
+	}
+	r := sortutil.Analyze(io.Discard, false, f, sortutil.Sizes(10))
+	if r.Failed() {
+		t.Errorf("analysis failed: %+v", r.Results)
+	}
+}
+
+func TestOracleFailure(t *testing.T) {
+	tests := []struct {
+		mode    string
+		timeout time.Duration
+		want    string
+	}{
+		{"hang", 100 * time.Millisecond, "killed after 100ms"},
+		{"bad", 0, `invalid request "Shuffle 0 1"`},
+	}
+	for _, test := range tests {
+		t.Setenv("SORTUTIL_ORACLE", test.mode)
+		func() {
+			defer func() {
+				if e := recover(); !strings.Contains(fmt.Sprint(e), test.want) {
+					t.Errorf("%s: got panic %v, want %s", test.mode, e, test.want)
+				}
+			}()
+			oracle([]string{os.Args[0]}, test.timeout)(sort.IntSlice{2, 1})
+		}()
+	}
+}
+
+func TestCheckNames(t *testing.T) {
+	tests := []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"a.so", "b.so", "sort -n"}, true},
+		{[]string{"a.so", "x/a.so"}, false},
+		{[]string{"cat", "cat"}, false},
+		{[]string{"a.so", " \t"}, false},
+		{[]string{".so"}, false},
+	}
+	for _, test := range tests {
+		if err := checkNames(test.args); (err == nil) != test.ok {
+			t.Errorf("checkNames(%q) = %v", test.args, err)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := sortutil.Analyze(io.Discard, false, sort.Sort, sortutil.Sizes(5))
+	for _, format := range []string{"csv", "html", "json", "junit", "markdown", "tap"} {
+		var b strings.Builder
+		if err := write(&b, r, format); err != nil || b.Len() == 0 {
+			t.Errorf("%s: wrote %d bytes, err %v", format, b.Len(), err)
+		}
+	}
+	if err := write(io.Discard, r, "xml"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
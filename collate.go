@@ -0,0 +1,45 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build collate
+
+package sortutil
+
+import (
+	"sort"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// CollatedStringSlice attaches the methods of sort.Interface to S, sorting
+// in increasing order according to the collation rules of C. It is only built
+// with the "collate" build tag, which requires golang.org/x/text.
+type CollatedStringSlice struct {
+	S []string
+	C *collate.Collator
+}
+
+// NewCollatedStringSlice returns a CollatedStringSlice ordering s by the
+// collation rules for the language tag. The result must not be used by
+// multiple goroutines at once.
+func NewCollatedStringSlice(s []string, tag language.Tag) CollatedStringSlice {
+	return CollatedStringSlice{s, collate.New(tag)}
+}
+
+func (c CollatedStringSlice) Len() int           { return len(c.S) }
+func (c CollatedStringSlice) Less(i, j int) bool { return c.C.CompareString(c.S[i], c.S[j]) < 0 }
+func (c CollatedStringSlice) Swap(i, j int)      { c.S[i], c.S[j] = c.S[j], c.S[i] }
+
+func (c CollatedStringSlice) Move(dst, src int)            { c.S[dst] = c.S[src] }
+func (c CollatedStringSlice) Load(i int) interface{}       { return c.S[i] }
+func (c CollatedStringSlice) Store(dst int, v interface{}) { c.S[dst] = v.(string) }
+func (c CollatedStringSlice) LessValue(a, b interface{}) bool {
+	return c.C.CompareString(a.(string), b.(string)) < 0
+}
+func (c CollatedStringSlice) Swap3(i, j, k int) { c.S[i], c.S[j], c.S[k] = c.S[k], c.S[i], c.S[j] }
+
+func (c CollatedStringSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return c.C.CompareString(c.S[i], other.(CollatedStringSlice).S[j]) < 0
+}
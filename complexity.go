@@ -0,0 +1,277 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Model is a candidate growth function for the operation count of an
+// algorithm.
+type Model struct {
+	Name string
+	F    func(n float64) float64
+}
+
+// Models are the growth functions considered by EstimateComplexity.
+var Models = []Model{
+	{"n", func(n float64) float64 { return n }},
+	{"n log n", func(n float64) float64 { return n * math.Log2(n) }},
+	{"n^2", func(n float64) float64 { return n * n }},
+}
+
+// Fit describes how well a Model, scaled by C, predicts observed operation
+// counts. Err is the root mean square of the relative prediction errors.
+type Fit struct {
+	Model string
+	C     float64
+	Err   float64
+}
+
+// ComplexityReport contains the results of EstimateComplexity. Ops holds the
+// total number of Less and Swap calls observed at each of Sizes, and Fits
+// holds the fit of each of Models, best first. Confidence ranges from 0, when
+// the best two fits are equally good, to 1, when the best fit is exact.
+type ComplexityReport struct {
+	Sizes      []int
+	Ops        []int64
+	Fits       []Fit
+	Confidence float64
+}
+
+// Best returns the name of the best fitting model.
+func (c ComplexityReport) Best() string {
+	if len(c.Fits) == 0 {
+		return ""
+	}
+	return c.Fits[0].Model
+}
+
+func (c ComplexityReport) String() string {
+	var b strings.Builder
+	for i, n := range c.Sizes {
+		fmt.Fprintf(&b, "n=%d: %d ops\n", n, c.Ops[i])
+	}
+	for _, f := range c.Fits {
+		fmt.Fprintf(&b, "%-8s C=%-10.4g err=%.4f\n", f.Model, f.C, f.Err)
+	}
+	fmt.Fprintf(&b, "best: %s (confidence %.2f)", c.Best(), c.Confidence)
+	return b.String()
+}
+
+// EstimateComplexity runs f on data produced by gen at each of the given
+// sizes, and fits the number of Less and Swap calls made against each of
+// Models. Sizes should span at least an order of magnitude for the fit to be
+// meaningful. The source passed to gen is seeded identically for each size.
+func EstimateComplexity(f func(sort.Interface), gen Generator, sizes []int) ComplexityReport {
+	c := ComplexityReport{Sizes: sizes, Ops: make([]int64, len(sizes))}
+	for i, n := range sizes {
+		s := &Stat{I: gen(n, rand.New(rand.NewSource(1)))}
+		f(s)
+		c.Ops[i] = s.N.Less + s.N.Swap
+	}
+	c.Fits, c.Confidence = fitAll(sizes, c.Ops)
+	return c
+}
+
+// fitAll fits each of Models to ops, returning the fits best first and the
+// confidence in the best, as described by ComplexityReport.
+func fitAll(sizes []int, ops []int64) ([]Fit, float64) {
+	var fits []Fit
+	for _, m := range Models {
+		fits = append(fits, fit(m, sizes, ops))
+	}
+	sort.SliceStable(fits, func(i, j int) bool { return fits[i].Err < fits[j].Err })
+	var conf float64
+	if len(fits) > 1 && fits[1].Err > 0 {
+		conf = 1 - fits[0].Err/fits[1].Err
+	}
+	return fits, conf
+}
+
+// fit scales m to minimize the relative squared error of its predictions of
+// ops. Sizes at which no operations were observed are ignored.
+func fit(m Model, sizes []int, ops []int64) Fit {
+	var sr, srr float64
+	var k int
+	for i, n := range sizes {
+		if ops[i] == 0 || n < 2 {
+			continue
+		}
+		r := m.F(float64(n)) / float64(ops[i])
+		sr += r
+		srr += r * r
+		k++
+	}
+	if k == 0 || srr == 0 {
+		return Fit{Model: m.Name, Err: math.Inf(1)}
+	}
+	c := sr / srr
+	var e float64
+	for i, n := range sizes {
+		if ops[i] == 0 || n < 2 {
+			continue
+		}
+		d := 1 - c*m.F(float64(n))/float64(ops[i])
+		e += d * d
+	}
+	return Fit{m.Name, c, math.Sqrt(e / float64(k))}
+}
+
+// DefaultScalingSizes are the sizes swept by Scaling when fewer than two are
+// given with the Sizes option.
+var DefaultScalingSizes = []int{100, 1000, 10000}
+
+// Scale describes how a sorting function scaled on one dataset. Ops holds the
+// total number of Less and Swap calls at each size, fitted as by
+// EstimateComplexity. Time holds the mean wall time in nanoseconds in place of
+// operation counts, fitted the same way.
+type Scale struct {
+	Name string
+	Ops  ComplexityReport
+	Time ComplexityReport
+}
+
+// ScalingReport contains the results of Scaling, in dataset order.
+type ScalingReport struct {
+	Scales []Scale
+}
+
+// Scaling runs every dataset used by Analyze through f at each of a sweep of
+// sizes, counting operations on one run and timing the others, and fits both
+// against Models. It is the quantitative counterpart of Analyze: f is assumed
+// to sort correctly.
+//
+// The Sizes, Seed, Timing, and Limit options are honored. Sizes defaults to
+// DefaultScalingSizes, and without Timing each size is timed once. A size
+// whose timed runs exceed the limit or panic is reported as taking no time.
+func Scaling(f func(sort.Interface), opts ...Option) ScalingReport {
+	c := newConfig(opts)
+	sizes := c.sizes
+	if len(sizes) < 2 {
+		sizes = DefaultScalingSizes
+	}
+	reps := c.reps
+	if reps <= 0 {
+		reps = 1
+	}
+	var r ScalingReport
+	for _, v := range cases {
+		s := Scale{
+			Name: v.Name,
+			Ops:  ComplexityReport{Sizes: sizes, Ops: make([]int64, len(sizes))},
+			Time: ComplexityReport{Sizes: sizes, Ops: make([]int64, len(sizes))},
+		}
+		for i, n := range sizes {
+			gen := func() sort.Interface { return v.Gen(n, rand.New(rand.NewSource(c.seed))) }
+			data := NewCount(gen())
+			protect(f, c.limit(data))
+			s.Ops.Ops[i] = data.N.Less + data.N.Swap
+			s.Time.Ops[i], _ = c.bench(reps, f, gen)
+		}
+		s.Ops.Fits, s.Ops.Confidence = fitAll(sizes, s.Ops.Ops)
+		s.Time.Fits, s.Time.Confidence = fitAll(sizes, s.Time.Ops)
+		r.Scales = append(r.Scales, s)
+	}
+	return r
+}
+
+func (r ScalingReport) String() string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\tn\tops\tns/op\t\n")
+	for _, s := range r.Scales {
+		for i, n := range s.Ops.Sizes {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t\n", s.Name, n, s.Ops.Ops[i], s.Time.Ops[i])
+		}
+	}
+	tw.Flush()
+	fmt.Fprint(&b, "\n")
+	tw = tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	fmt.Fprint(tw, "\tops\t\ttime\t\n")
+	for _, s := range r.Scales {
+		fmt.Fprintf(tw, "%s\t%s\t(%.2f)\t%s\t(%.2f)\n",
+			s.Name, s.Ops.Best(), s.Ops.Confidence, s.Time.Best(), s.Time.Confidence)
+	}
+	tw.Flush()
+	return b.String()
+}
+
+var scalingHeader = []string{
+	"name", "len", "ops", "ns_per_op",
+	"ops_model", "ops_c", "ops_err", "time_model", "time_c", "time_err",
+}
+
+// WriteCSV writes r to w as CSV, one record per dataset and size following a
+// header record. The best fits of each dataset are repeated on every record.
+func (r ScalingReport) WriteCSV(w io.Writer) error {
+	c := csv.NewWriter(w)
+	c.Write(scalingHeader)
+	for _, s := range r.Scales {
+		var fits []string
+		for _, f := range []ComplexityReport{s.Ops, s.Time} {
+			if len(f.Fits) == 0 {
+				fits = append(fits, "", "", "")
+				continue
+			}
+			b := f.Fits[0]
+			fits = append(fits, b.Model, fmt.Sprint(b.C), fmt.Sprint(b.Err))
+		}
+		for i, n := range s.Ops.Sizes {
+			rec := []string{s.Name, strconv.Itoa(n), strconv.FormatInt(s.Ops.Ops[i], 10), strconv.FormatInt(s.Time.Ops[i], 10)}
+			c.Write(append(rec, fits...))
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// Adaptivity contains the results of AdaptivityReport. Sorted, Nearly, and
+// Random hold the total number of Less and Swap calls made on ascending,
+// nearly sorted, and shuffled inputs of length Len, and SortedRatio and
+// NearlyRatio hold the first two relative to Random. An algorithm that does
+// not exploit presortedness has ratios near 1; one that does has ratios near
+// 0 for large Len.
+type Adaptivity struct {
+	Len                      int
+	Sorted, Nearly, Random   int64
+	SortedRatio, NearlyRatio float64
+}
+
+func (a Adaptivity) String() string {
+	return fmt.Sprintf("n=%d: sorted %d ops (%.3f), nearly sorted %d ops (%.3f), random %d ops",
+		a.Len, a.Sorted, a.SortedRatio, a.Nearly, a.NearlyRatio, a.Random)
+}
+
+// AdaptivityReport runs f on inputs of length n that are ascending, nearly
+// sorted, with each element displaced by at most 1% of n, and randomly
+// shuffled, and compares the number of operations made on each.
+func AdaptivityReport(f func(sort.Interface), n int) Adaptivity {
+	ops := func(data sort.Interface) int64 {
+		c := NewCount(data)
+		f(c)
+		return c.N.Less + c.N.Swap
+	}
+	r := rand.New(rand.NewSource(1))
+	a := Adaptivity{
+		Len:    n,
+		Sorted: ops(NewIntSeq(n)),
+		Nearly: ops(NewNearlySorted(n, max(1, n/100), r)),
+		Random: ops(sort.IntSlice(r.Perm(n))),
+	}
+	if a.Random > 0 {
+		a.SortedRatio = float64(a.Sorted) / float64(a.Random)
+		a.NearlyRatio = float64(a.Nearly) / float64(a.Random)
+	}
+	return a
+}
@@ -4,7 +4,18 @@
 
 package sortutil
 
-import "sort"
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"math/rand"
+	"net/netip"
+	"reflect"
+	"sort"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
 // ByteSlice attaches the methods of sort.Interface to []byte,
 // sorting in increasing order.
@@ -15,6 +26,25 @@ func (b ByteSlice) Less(i, j int) bool { return b[i] < b[j] }
 func (b ByteSlice) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
 func (b ByteSlice) String() string     { return string(b) }
 
+func (b ByteSlice) Move(dst, src int)             { b[dst] = b[src] }
+func (b ByteSlice) Swap3(i, j, k int)             { b[i], b[j], b[k] = b[k], b[i], b[j] }
+func (b ByteSlice) Load(i int) interface{}        { return b[i] }
+func (b ByteSlice) Store(dst int, v interface{})  { b[dst] = v.(byte) }
+func (ByteSlice) LessValue(a, b interface{}) bool { return a.(byte) < b.(byte) }
+
+func (b ByteSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return b[i] < other.(ByteSlice)[j]
+}
+
+// Snapshot returns the value of each element, for use with Audit.
+func (b ByteSlice) Snapshot() []uint64 {
+	fp := make([]uint64, len(b))
+	for i, v := range b {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
 // NewLetterSeq returns an ascending Letters sequence of length n.
 // If n is greater than 26, the sequence will be duplicated, starting
 // again with 'a'.
@@ -26,6 +56,49 @@ func NewLetterSeq(n int) Letters {
 	return l
 }
 
+// NewRandLetters returns a reproducible random Letters sequence of length n,
+// drawn from the first distinct letters of the alphabet. If n >= distinct,
+// each of those letters appears at least once; otherwise, no letter appears
+// more than once. NewRandLetters will panic
+// unless 1 <= distinct <= 26.
+func NewRandLetters(n, distinct int, seed int64) Letters {
+	if distinct < 1 || distinct > 26 {
+		panic(panicmsg)
+	}
+	l := make(Letters, n)
+	for i, v := range randDistinct(n, distinct, seed) {
+		l[i] = 'a' + byte(v)
+	}
+	return l
+}
+
+// NewRandInts is like NewRandLetters, but draws from the ints [0,distinct).
+// NewRandInts will panic if distinct < 1.
+func NewRandInts(n, distinct int, seed int64) sort.IntSlice {
+	if distinct < 1 {
+		panic(panicmsg)
+	}
+	return randDistinct(n, distinct, seed)
+}
+
+// randDistinct returns n values in [0,distinct), determined by seed. Each
+// value is included at least once if n >= distinct; otherwise, the values are
+// distinct.
+func randDistinct(n, distinct int, seed int64) sort.IntSlice {
+	r := rand.New(rand.NewSource(seed))
+	p := r.Perm(distinct)
+	s := make(sort.IntSlice, n)
+	for i := range s {
+		if i < distinct {
+			s[i] = p[i]
+		} else {
+			s[i] = r.Intn(distinct)
+		}
+	}
+	ShuffleR(s, r)
+	return s
+}
+
 // Letters is designed for developing and debugging sorting algorithms,
 // and should contain only bytes in the ASCII lowercase letter range.
 type Letters []byte
@@ -35,6 +108,25 @@ func (l Letters) Less(i, j int) bool { return l[i] < l[j] }
 func (l Letters) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
 func (l Letters) String() string     { return string(l) }
 
+func (l Letters) Move(dst, src int)             { l[dst] = l[src] }
+func (l Letters) Swap3(i, j, k int)             { l[i], l[j], l[k] = l[k], l[i], l[j] }
+func (l Letters) Load(i int) interface{}        { return l[i] }
+func (l Letters) Store(dst int, v interface{})  { l[dst] = v.(byte) }
+func (Letters) LessValue(a, b interface{}) bool { return a.(byte) < b.(byte) }
+
+func (l Letters) CrossLess(i int, other sort.Interface, j int) bool {
+	return l[i] < other.(Letters)[j]
+}
+
+// Snapshot returns the value of each element, for use with Audit.
+func (l Letters) Snapshot() []uint64 {
+	fp := make([]uint64, len(l))
+	for i, v := range l {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
 // Mark behaves like String, except the specified indices will be uppercased.
 func (l Letters) Mark(i, j int) string {
 	c := make(Letters, len(l))
@@ -45,11 +137,449 @@ func (l Letters) Mark(i, j int) string {
 	return string(c)
 }
 
+// MarkRange behaves like String, except the letters in [i,j) will be
+// uppercased.
+func (l Letters) MarkRange(i, j int) string {
+	c := make(Letters, len(l))
+	copy(c, l)
+	for k := i; k < j; k++ {
+		c[k] -= 'a' - 'A'
+	}
+	return string(c)
+}
+
 // NewIntSeq returns an ascending int sequence, starting with zero.
 func NewIntSeq(n int) sort.IntSlice {
+	return NewSeq(0, 1, n)
+}
+
+// NewDescSeq returns a descending int sequence, ending with zero.
+func NewDescSeq(n int) sort.IntSlice {
+	return NewSeq(n-1, -1, n)
+}
+
+// NewSeq returns an int sequence of length n, starting with start, and
+// increasing by step with each element. NewSeq(0, 2, 4) returns [0 2 4 6].
+func NewSeq(start, step, n int) sort.IntSlice {
 	s := make(sort.IntSlice, n)
 	for i := range s {
-		s[i] = i
+		s[i] = start + i*step
+	}
+	return s
+}
+
+// NewRepeat returns an int sequence consisting of count copies of values, as
+// strings.Repeat does for strings. NewRepeat([]int{1, 2}, 2) returns
+// [1 2 1 2].
+func NewRepeat(values []int, count int) sort.IntSlice {
+	s := make(sort.IntSlice, 0, len(values)*count)
+	for i := 0; i < count; i++ {
+		s = append(s, values...)
 	}
 	return s
 }
+
+// Any attaches the methods of sort.Interface to an arbitrary slice, ordered by
+// less, as with sort.Slice. Any will panic if slice is not a slice.
+func Any(slice interface{}, less func(i, j int) bool) sort.Interface {
+	return anySlice{reflect.ValueOf(slice).Len(), less, reflect.Swapper(slice)}
+}
+
+type anySlice struct {
+	n    int
+	less func(i, j int) bool
+	swap func(i, j int)
+}
+
+func (a anySlice) Len() int           { return a.n }
+func (a anySlice) Less(i, j int) bool { return a.less(i, j) }
+func (a anySlice) Swap(i, j int)      { a.swap(i, j) }
+
+// NaNPolicy determines where a Float64Slice places NaN values.
+type NaNPolicy int
+
+const (
+	NaNFirst NaNPolicy = iota // NaNs sort before all other values, as in sort.Float64Slice
+	NaNLast                   // NaNs sort after all other values
+	NaNPanic                  // comparing a NaN panics
+)
+
+// Float64Slice attaches the methods of sort.Interface to S, sorting in
+// increasing order, with NaN values placed according to NaN. Unlike a naive
+// comparison, every policy is a strict weak ordering.
+type Float64Slice struct {
+	S   []float64
+	NaN NaNPolicy
+}
+
+func (f Float64Slice) Len() int      { return len(f.S) }
+func (f Float64Slice) Swap(i, j int) { f.S[i], f.S[j] = f.S[j], f.S[i] }
+
+func (f Float64Slice) Move(dst, src int)               { f.S[dst] = f.S[src] }
+func (f Float64Slice) Load(i int) interface{}          { return f.S[i] }
+func (f Float64Slice) Store(dst int, v interface{})    { f.S[dst] = v.(float64) }
+func (f Float64Slice) LessValue(a, b interface{}) bool { return f.less(a.(float64), b.(float64)) }
+func (f Float64Slice) Swap3(i, j, k int)               { f.S[i], f.S[j], f.S[k] = f.S[k], f.S[i], f.S[j] }
+
+// Snapshot returns the value of each element, for use with Audit.
+func (f Float64Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(f.S))
+	for i, v := range f.S {
+		fp[i] = math.Float64bits(v)
+	}
+	return fp
+}
+
+func (f Float64Slice) Less(i, j int) bool { return f.less(f.S[i], f.S[j]) }
+
+func (f Float64Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return f.less(f.S[i], other.(Float64Slice).S[j])
+}
+
+func (f Float64Slice) less(a, b float64) bool {
+	x, y := math.IsNaN(a), math.IsNaN(b)
+	if !x && !y {
+		return a < b
+	}
+	switch f.NaN {
+	case NaNLast:
+		return !x && y
+	case NaNPanic:
+		panic("sortutil: NaN in Float64Slice")
+	}
+	return x && !y
+}
+
+// FoldedStringSlice attaches the methods of sort.Interface to []string,
+// sorting in increasing order under Unicode simple case folding, so that
+// "apple" and "Apple" compare equal and both precede "banana".
+type FoldedStringSlice []string
+
+func (f FoldedStringSlice) Len() int           { return len(f) }
+func (f FoldedStringSlice) Less(i, j int) bool { return lessFold(f[i], f[j]) }
+func (f FoldedStringSlice) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+func (f FoldedStringSlice) Move(dst, src int)             { f[dst] = f[src] }
+func (f FoldedStringSlice) Load(i int) interface{}        { return f[i] }
+func (f FoldedStringSlice) Store(dst int, v interface{})  { f[dst] = v.(string) }
+func (FoldedStringSlice) LessValue(a, b interface{}) bool { return lessFold(a.(string), b.(string)) }
+func (f FoldedStringSlice) Swap3(i, j, k int)             { f[i], f[j], f[k] = f[k], f[i], f[j] }
+
+func (f FoldedStringSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return lessFold(f[i], other.(FoldedStringSlice)[j])
+}
+
+func lessFold(a, b string) bool {
+	for a != "" && b != "" {
+		r, n := utf8.DecodeRuneInString(a)
+		s, m := utf8.DecodeRuneInString(b)
+		if r, s = foldRune(r), foldRune(s); r != s {
+			return r < s
+		}
+		a, b = a[n:], b[m:]
+	}
+	return a == "" && b != ""
+}
+
+// foldRune returns the least rune equivalent to r under simple case folding.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// TimeSlice attaches the methods of sort.Interface to []time.Time, sorting in
+// increasing order. Times are printed in RFC 3339 format.
+type TimeSlice []time.Time
+
+func (t TimeSlice) Len() int           { return len(t) }
+func (t TimeSlice) Less(i, j int) bool { return t[i].Before(t[j]) }
+func (t TimeSlice) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+func (t TimeSlice) Move(dst, src int)             { t[dst] = t[src] }
+func (t TimeSlice) Load(i int) interface{}        { return t[i] }
+func (t TimeSlice) Store(dst int, v interface{})  { t[dst] = v.(time.Time) }
+func (TimeSlice) LessValue(a, b interface{}) bool { return a.(time.Time).Before(b.(time.Time)) }
+func (t TimeSlice) Swap3(i, j, k int)             { t[i], t[j], t[k] = t[k], t[i], t[j] }
+func (t TimeSlice) String() string                { return t.Mark(-1, -1) }
+
+func (t TimeSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return t[i].Before(other.(TimeSlice)[j])
+}
+
+// Mark behaves like String, except the specified indices will be enclosed in
+// angle brackets.
+func (t TimeSlice) Mark(i, j int) string {
+	return markList(len(t), i, j, func(k int) string { return t[k].Format(time.RFC3339Nano) })
+}
+
+// DurationSlice attaches the methods of sort.Interface to []time.Duration,
+// sorting in increasing order.
+type DurationSlice []time.Duration
+
+func (d DurationSlice) Len() int           { return len(d) }
+func (d DurationSlice) Less(i, j int) bool { return d[i] < d[j] }
+func (d DurationSlice) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+func (d DurationSlice) Move(dst, src int)             { d[dst] = d[src] }
+func (d DurationSlice) Load(i int) interface{}        { return d[i] }
+func (d DurationSlice) Store(dst int, v interface{})  { d[dst] = v.(time.Duration) }
+func (DurationSlice) LessValue(a, b interface{}) bool { return a.(time.Duration) < b.(time.Duration) }
+func (d DurationSlice) Swap3(i, j, k int)             { d[i], d[j], d[k] = d[k], d[i], d[j] }
+func (d DurationSlice) String() string                { return d.Mark(-1, -1) }
+
+func (d DurationSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return d[i] < other.(DurationSlice)[j]
+}
+
+// Mark behaves like String, except the specified indices will be enclosed in
+// angle brackets.
+func (d DurationSlice) Mark(i, j int) string {
+	return markList(len(d), i, j, func(k int) string { return d[k].String() })
+}
+
+// markList formats n elements in the style of fmt.Sprint, enclosing elements
+// i and j in angle brackets.
+func markList(n, i, j int, f func(k int) string) string {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for k := 0; k < n; k++ {
+		if k > 0 {
+			b.WriteByte(' ')
+		}
+		if k == i || k == j {
+			b.WriteString("<" + f(k) + ">")
+		} else {
+			b.WriteString(f(k))
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// AddrSlice attaches the methods of sort.Interface to []netip.Addr, sorting
+// by address family, with IPv4 preceding IPv6, then by numeric value, then
+// by zone.
+type AddrSlice []netip.Addr
+
+func (a AddrSlice) Len() int           { return len(a) }
+func (a AddrSlice) Less(i, j int) bool { return a[i].Less(a[j]) }
+func (a AddrSlice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+func (a AddrSlice) Move(dst, src int)             { a[dst] = a[src] }
+func (a AddrSlice) Load(i int) interface{}        { return a[i] }
+func (a AddrSlice) Store(dst int, v interface{})  { a[dst] = v.(netip.Addr) }
+func (AddrSlice) LessValue(a, b interface{}) bool { return a.(netip.Addr).Less(b.(netip.Addr)) }
+func (a AddrSlice) Swap3(i, j, k int)             { a[i], a[j], a[k] = a[k], a[i], a[j] }
+func (a AddrSlice) String() string                { return a.Mark(-1, -1) }
+
+func (a AddrSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return a[i].Less(other.(AddrSlice)[j])
+}
+
+// Mark behaves like String, except the specified indices will be enclosed in
+// angle brackets.
+func (a AddrSlice) Mark(i, j int) string {
+	return markList(len(a), i, j, func(k int) string { return a[k].String() })
+}
+
+// PrefixSlice attaches the methods of sort.Interface to []netip.Prefix,
+// sorting by address, as AddrSlice does, then by increasing prefix length.
+type PrefixSlice []netip.Prefix
+
+func (p PrefixSlice) Len() int      { return len(p) }
+func (p PrefixSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+func (p PrefixSlice) Move(dst, src int)            { p[dst] = p[src] }
+func (p PrefixSlice) Load(i int) interface{}       { return p[i] }
+func (p PrefixSlice) Store(dst int, v interface{}) { p[dst] = v.(netip.Prefix) }
+func (PrefixSlice) LessValue(a, b interface{}) bool {
+	return lessPrefix(a.(netip.Prefix), b.(netip.Prefix))
+}
+func (p PrefixSlice) Swap3(i, j, k int) { p[i], p[j], p[k] = p[k], p[i], p[j] }
+func (p PrefixSlice) String() string    { return p.Mark(-1, -1) }
+
+func (p PrefixSlice) Less(i, j int) bool { return lessPrefix(p[i], p[j]) }
+
+func (p PrefixSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return lessPrefix(p[i], other.(PrefixSlice)[j])
+}
+
+func lessPrefix(a, b netip.Prefix) bool {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c < 0
+	}
+	return a.Bits() < b.Bits()
+}
+
+// Mark behaves like String, except the specified indices will be enclosed in
+// angle brackets.
+func (p PrefixSlice) Mark(i, j int) string {
+	return markList(len(p), i, j, func(k int) string { return p[k].String() })
+}
+
+// BigIntSlice attaches the methods of sort.Interface to []*big.Int, sorting
+// in increasing order. Since each comparison may be expensive, consider
+// sorting indirectly, or wrapping with Stat to count comparisons.
+type BigIntSlice []*big.Int
+
+func (b BigIntSlice) Len() int           { return len(b) }
+func (b BigIntSlice) Less(i, j int) bool { return b[i].Cmp(b[j]) < 0 }
+func (b BigIntSlice) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+func (b BigIntSlice) Move(dst, src int)             { b[dst] = b[src] }
+func (b BigIntSlice) Load(i int) interface{}        { return b[i] }
+func (b BigIntSlice) Store(dst int, v interface{})  { b[dst] = v.(*big.Int) }
+func (BigIntSlice) LessValue(a, b interface{}) bool { return a.(*big.Int).Cmp(b.(*big.Int)) < 0 }
+func (b BigIntSlice) Swap3(i, j, k int)             { b[i], b[j], b[k] = b[k], b[i], b[j] }
+
+func (b BigIntSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return b[i].Cmp(other.(BigIntSlice)[j]) < 0
+}
+
+// BigFloatSlice attaches the methods of sort.Interface to []*big.Float,
+// sorting in increasing order.
+type BigFloatSlice []*big.Float
+
+func (b BigFloatSlice) Len() int           { return len(b) }
+func (b BigFloatSlice) Less(i, j int) bool { return b[i].Cmp(b[j]) < 0 }
+func (b BigFloatSlice) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+func (b BigFloatSlice) Move(dst, src int)             { b[dst] = b[src] }
+func (b BigFloatSlice) Load(i int) interface{}        { return b[i] }
+func (b BigFloatSlice) Store(dst int, v interface{})  { b[dst] = v.(*big.Float) }
+func (BigFloatSlice) LessValue(a, b interface{}) bool { return a.(*big.Float).Cmp(b.(*big.Float)) < 0 }
+func (b BigFloatSlice) Swap3(i, j, k int)             { b[i], b[j], b[k] = b[k], b[i], b[j] }
+
+func (b BigFloatSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return b[i].Cmp(other.(BigFloatSlice)[j]) < 0
+}
+
+// RatSlice attaches the methods of sort.Interface to []*big.Rat, sorting in
+// increasing order.
+type RatSlice []*big.Rat
+
+func (r RatSlice) Len() int           { return len(r) }
+func (r RatSlice) Less(i, j int) bool { return r[i].Cmp(r[j]) < 0 }
+func (r RatSlice) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+func (r RatSlice) Move(dst, src int)             { r[dst] = r[src] }
+func (r RatSlice) Load(i int) interface{}        { return r[i] }
+func (r RatSlice) Store(dst int, v interface{})  { r[dst] = v.(*big.Rat) }
+func (RatSlice) LessValue(a, b interface{}) bool { return a.(*big.Rat).Cmp(b.(*big.Rat)) < 0 }
+func (r RatSlice) Swap3(i, j, k int)             { r[i], r[j], r[k] = r[k], r[i], r[j] }
+
+func (r RatSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return r[i].Cmp(other.(RatSlice)[j]) < 0
+}
+
+// Alphabets for use with NewSymbolSeq.
+var (
+	LowerAlphabet = []rune("abcdefghijklmnopqrstuvwxyz")
+	UpperAlphabet = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	DigitAlphabet = []rune("0123456789")
+	GreekAlphabet = []rune("αβγδεζηθικλμνξοπρστυφχψω")
+	EmojiAlphabet = []rune("😀😁😂😃😄😅😆😇😈😉😊😋😌😍😎😏😐😑😒😓😔😕😖😗😘😙😚😛😜😝😞😟")
+)
+
+// Symbols generalizes Letters to an arbitrary alphabet. Each element of S is
+// an index into Alphabet, and elements are ordered by index, so that the
+// order of the alphabet determines the sort order.
+type Symbols struct {
+	S        []int
+	Alphabet []rune
+}
+
+// NewSymbolSeq returns an ascending Symbols sequence of length n over
+// alphabet. If n is greater than len(alphabet), the sequence will be
+// duplicated, starting again with the first symbol.
+func NewSymbolSeq(n int, alphabet []rune) Symbols {
+	s := Symbols{make([]int, n), alphabet}
+	for i := range s.S {
+		s.S[i] = i % len(alphabet)
+	}
+	return s
+}
+
+func (s Symbols) Len() int           { return len(s.S) }
+func (s Symbols) Less(i, j int) bool { return s.S[i] < s.S[j] }
+func (s Symbols) Swap(i, j int)      { s.S[i], s.S[j] = s.S[j], s.S[i] }
+func (s Symbols) String() string     { return s.Mark(-1, -1) }
+
+func (s Symbols) Move(dst, src int)             { s.S[dst] = s.S[src] }
+func (s Symbols) Load(i int) interface{}        { return s.S[i] }
+func (s Symbols) Store(dst int, v interface{})  { s.S[dst] = v.(int) }
+func (Symbols) LessValue(a, b interface{}) bool { return a.(int) < b.(int) }
+func (s Symbols) Swap3(i, j, k int)             { s.S[i], s.S[j], s.S[k] = s.S[k], s.S[i], s.S[j] }
+
+func (s Symbols) CrossLess(i int, other sort.Interface, j int) bool {
+	return s.S[i] < other.(Symbols).S[j]
+}
+
+// Mark behaves like String, except the symbols at the specified indices will
+// be underlined by a combining character, which preserves the visible length
+// for alphabets without case, such as digits and emoji.
+func (s Symbols) Mark(i, j int) string {
+	b := make([]rune, 0, len(s.S)+2)
+	for k, v := range s.S {
+		b = append(b, s.Alphabet[v])
+		if k == i || k == j {
+			b = append(b, '̲')
+		}
+	}
+	return string(b)
+}
+
+// MarkRange behaves like Mark, except every symbol in [i,j) is underlined.
+func (s Symbols) MarkRange(i, j int) string {
+	b := make([]rune, 0, len(s.S)+j-i)
+	for k, v := range s.S {
+		b = append(b, s.Alphabet[v])
+		if i <= k && k < j {
+			b = append(b, '̲')
+		}
+	}
+	return string(b)
+}
+
+// Bars attaches the methods of sort.Interface to []int, sorting in increasing
+// order, and prints as a line of Unicode block characters, as Spark does, so
+// that Log shows the shape of the data rather than its values.
+type Bars []int
+
+func (b Bars) Len() int           { return len(b) }
+func (b Bars) Less(i, j int) bool { return b[i] < b[j] }
+func (b Bars) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b Bars) String() string     { return Spark(b) }
+
+func (b Bars) Move(dst, src int)             { b[dst] = b[src] }
+func (b Bars) Load(i int) interface{}        { return b[i] }
+func (b Bars) Store(dst int, v interface{})  { b[dst] = v.(int) }
+func (Bars) LessValue(a, b interface{}) bool { return a.(int) < b.(int) }
+func (b Bars) Swap3(i, j, k int)             { b[i], b[j], b[k] = b[k], b[i], b[j] }
+
+func (b Bars) CrossLess(i int, other sort.Interface, j int) bool {
+	return b[i] < other.(Bars)[j]
+}
+
+// Mark behaves like String, except the blocks at the specified indices will be
+// colored with ANSI escapes, as by ColorMarker.
+func (b Bars) Mark(i, j int) string {
+	r := []rune(Spark(b))
+	var buf bytes.Buffer
+	for k, c := range r {
+		switch k {
+		case i:
+			buf.WriteString(colorI + string(c) + colorReset)
+		case j:
+			buf.WriteString(colorJ + string(c) + colorReset)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String()
+}
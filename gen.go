@@ -0,0 +1,423 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The following Generators produce the test distributions described by
+// Bentley and McIlroy in "Engineering a Sort Function", each parameterized by
+// m. All of them return a sort.IntSlice.
+
+// Sawtooth generates i%m at each index i.
+func Sawtooth(m int) Generator {
+	return ints(func(i, n int, r *rand.Rand) int { return i % m })
+}
+
+// RandMod generates random values in [0,m).
+func RandMod(m int) Generator {
+	return ints(func(i, n int, r *rand.Rand) int { return r.Intn(m) })
+}
+
+// Stagger generates (i*m+i)%n at each index i.
+func Stagger(m int) Generator {
+	return ints(func(i, n int, r *rand.Rand) int { return (i*m + i) % n })
+}
+
+// Plateau generates min(i,m) at each index i.
+func Plateau(m int) Generator {
+	return ints(func(i, n int, r *rand.Rand) int {
+		if i < m {
+			return i
+		}
+		return m
+	})
+}
+
+// Shuffled generates, at random with probability 1/m, either the next of the
+// ascending even values or the next of the ascending odd values.
+func Shuffled(m int) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		s := make(sort.IntSlice, n)
+		j, k := 0, 1
+		for i := range s {
+			if r.Intn(m) == 0 {
+				s[i], j = j, j+2
+			} else {
+				s[i], k = k, k+2
+			}
+		}
+		return s
+	}
+}
+
+// ints returns a Generator of the sort.IntSlice whose element at index i is
+// f(i, n, r).
+func ints(f func(i, n int, r *rand.Rand) int) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		s := make(sort.IntSlice, n)
+		for i := range s {
+			s[i] = f(i, n, r)
+		}
+		return s
+	}
+}
+
+// Reversed returns a Generator of the data produced by g, in reverse order.
+func Reversed(g Generator) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		data := g(n, r)
+		Reverse(data)
+		return data
+	}
+}
+
+// ReversedFront returns a Generator of the data produced by g, with the first
+// half reversed.
+func ReversedFront(g Generator) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		data := g(n, r)
+		Reverse(NewSub(data, 0, data.Len()/2))
+		return data
+	}
+}
+
+// ReversedBack returns a Generator of the data produced by g, with the second
+// half reversed.
+func ReversedBack(g Generator) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		data := g(n, r)
+		Reverse(NewSub(data, data.Len()/2, data.Len()))
+		return data
+	}
+}
+
+// Sorted returns a Generator of the data produced by g, in sorted order.
+func Sorted(g Generator) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		data := g(n, r)
+		sort.Sort(data)
+		return data
+	}
+}
+
+// Dithered returns a Generator of the data produced by g, with i%5 added to
+// the element at each index i. g must produce a sort.IntSlice.
+func Dithered(g Generator) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		data := g(n, r).(sort.IntSlice)
+		for i := range data {
+			data[i] += i % 5
+		}
+		return data
+	}
+}
+
+// BentleyMcIlroy returns the full Bentley-McIlroy test suite for length n:
+// each of the five distributions, for each m in 1, 2, 4, ... less than 2n,
+// in each of the six variants (copied, reversed, front half reversed, back
+// half reversed, sorted, and dithered). The cases are named after their
+// parameters, such as "sawtooth m=4 reverse", and may be run by passing
+// them to RegisterCase.
+func BentleyMcIlroy(n int) []Case {
+	dists := []struct {
+		name string
+		f    func(m int) Generator
+	}{
+		{"sawtooth", Sawtooth},
+		{"rand", RandMod},
+		{"stagger", Stagger},
+		{"plateau", Plateau},
+		{"shuffle", Shuffled},
+	}
+	variants := []struct {
+		name string
+		f    func(Generator) Generator
+	}{
+		{"copy", func(g Generator) Generator { return g }},
+		{"reverse", Reversed},
+		{"reverse front", ReversedFront},
+		{"reverse back", ReversedBack},
+		{"sort", Sorted},
+		{"dither", Dithered},
+	}
+	var c []Case
+	for _, d := range dists {
+		for m := 1; m < 2*n; m *= 2 {
+			for _, v := range variants {
+				c = append(c, Case{fmt.Sprintf("%s m=%d %s", d.name, m, v.name), v.f(d.f(m))})
+			}
+		}
+	}
+	return c
+}
+
+// Adversary implements McIlroy's "killer adversary for quicksort". Rather
+// than holding fixed data, it decides the outcome of each Less call as the
+// sort proceeds, committing to values only when forced to, in a way that
+// drives pivot-based algorithms toward quadratic behavior. After sorting an
+// Adversary, Input returns data that reproduces the same behavior when sorted
+// by a deterministic algorithm.
+type Adversary struct {
+	val       []int // value of each element; gas if not yet frozen
+	ptr       []int // element at each position
+	nsolid    int
+	candidate int
+}
+
+// NewAdversary returns an Adversary of length n.
+func NewAdversary(n int) *Adversary {
+	a := &Adversary{val: make([]int, n), ptr: make([]int, n), candidate: -1}
+	for i := range a.val {
+		a.val[i], a.ptr[i] = n, i
+	}
+	return a
+}
+
+func (a *Adversary) gas() int { return len(a.val) }
+
+func (a *Adversary) Len() int      { return len(a.ptr) }
+func (a *Adversary) Swap(i, j int) { a.ptr[i], a.ptr[j] = a.ptr[j], a.ptr[i] }
+
+func (a *Adversary) Less(i, j int) bool {
+	x, y := a.ptr[i], a.ptr[j]
+	gas := a.gas()
+	if a.val[x] == gas && a.val[y] == gas {
+		if x == a.candidate {
+			a.freeze(x)
+		} else {
+			a.freeze(y)
+		}
+	}
+	if a.val[x] == gas {
+		a.candidate = x
+	} else if a.val[y] == gas {
+		a.candidate = y
+	}
+	return a.val[x] < a.val[y]
+}
+
+func (a *Adversary) freeze(x int) {
+	a.val[x] = a.nsolid
+	a.nsolid++
+}
+
+// Input returns the values committed to by a, in original order, as a
+// permutation of [0,n). Elements never forced to a value are assigned the
+// largest values, in order.
+func (a *Adversary) Input() sort.IntSlice {
+	s := make(sort.IntSlice, len(a.val))
+	k := a.nsolid
+	for i, v := range a.val {
+		if v == a.gas() {
+			v = k
+			k++
+		}
+		s[i] = v
+	}
+	return s
+}
+
+// NewNearlySorted returns a permutation of [0,n) in which each value v is
+// located no more than maxDisplacement positions from index v, drawing
+// randomness from src.
+func NewNearlySorted(n, maxDisplacement int, src rand.Source) sort.IntSlice {
+	r := rand.New(src)
+	s := NewIntSeq(n)
+	// Each value is displaced by sorting on a key jittered forward by less
+	// than maxDisplacement+1. At most maxDisplacement keys of greater values
+	// can precede the key of a given value, and vice versa.
+	keys := make(sort.Float64Slice, n)
+	for i := range keys {
+		keys[i] = float64(i) + r.Float64()*float64(maxDisplacement+1)
+	}
+	sort.Sort(NewProxy(keys, s))
+	return s
+}
+
+// NewSwapped returns the ascending sequence [0,n) after applying exactly k
+// transpositions of distinct, randomly chosen indices, drawing randomness from
+// src. Since later transpositions may undo earlier ones, the result may have
+// fewer than k elements out of place. If n is less than 2, no transpositions
+// are possible, and the sequence is returned unchanged.
+func NewSwapped(n, k int, src rand.Source) sort.IntSlice {
+	r := rand.New(src)
+	s := NewIntSeq(n)
+	for ; k > 0 && n > 1; k-- {
+		i := r.Intn(n)
+		j := r.Intn(n - 1)
+		if j >= i {
+			j++
+		}
+		s.Swap(i, j)
+	}
+	return s
+}
+
+// Swapped returns a Generator of NewSwapped(n, k, r).
+func Swapped(k int) Generator {
+	return func(n int, r *rand.Rand) sort.Interface { return NewSwapped(n, k, r) }
+}
+
+// NewFewUnique returns n values chosen uniformly from [0,distinct), drawing
+// randomness from src. See RandMod for the equivalent Generator.
+func NewFewUnique(n, distinct int, src rand.Source) sort.IntSlice {
+	return RandMod(distinct)(n, rand.New(src)).(sort.IntSlice)
+}
+
+// NewSkewed returns n values from [0,distinct) in which each value is zero
+// with probability p, and otherwise chosen uniformly from [1,distinct),
+// drawing randomness from src. For example, a p of 0.9 produces data in which
+// about 90% of the values are identical.
+func NewSkewed(n, distinct int, p float64, src rand.Source) sort.IntSlice {
+	return Skewed(distinct, p)(n, rand.New(src)).(sort.IntSlice)
+}
+
+// Skewed returns a Generator of NewSkewed(n, distinct, p, r).
+func Skewed(distinct int, p float64) Generator {
+	return ints(func(i, n int, r *rand.Rand) int {
+		if distinct < 2 || r.Float64() < p {
+			return 0
+		}
+		return 1 + r.Intn(distinct-1)
+	})
+}
+
+// NewGaussian returns n values drawn from a normal distribution with the given
+// mean and standard deviation, drawing randomness from src.
+func NewGaussian(n int, mean, stddev float64, src rand.Source) sort.Float64Slice {
+	return Gaussian(mean, stddev)(n, rand.New(src)).(sort.Float64Slice)
+}
+
+// Gaussian returns a Generator of NewGaussian(n, mean, stddev, r).
+func Gaussian(mean, stddev float64) Generator {
+	return floats(func(r *rand.Rand) float64 { return mean + stddev*r.NormFloat64() })
+}
+
+// NewExponential returns n values drawn from an exponential distribution with
+// the given rate parameter, drawing randomness from src.
+func NewExponential(n int, rate float64, src rand.Source) sort.Float64Slice {
+	return Exponential(rate)(n, rand.New(src)).(sort.Float64Slice)
+}
+
+// Exponential returns a Generator of NewExponential(n, rate, r).
+func Exponential(rate float64) Generator {
+	return floats(func(r *rand.Rand) float64 { return r.ExpFloat64() / rate })
+}
+
+// NewZipf returns n values in [0,imax] drawn from a Zipf distribution with
+// parameters s > 1 and v >= 1, as described by rand.NewZipf, drawing
+// randomness from src. Small values are the most frequent.
+func NewZipf(n int, s, v float64, imax uint64, src rand.Source) sort.IntSlice {
+	return Zipf(s, v, imax)(n, rand.New(src)).(sort.IntSlice)
+}
+
+// Zipf returns a Generator of NewZipf(n, s, v, imax, r).
+func Zipf(s, v float64, imax uint64) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		z := rand.NewZipf(r, s, v, imax)
+		d := make(sort.IntSlice, n)
+		for i := range d {
+			d[i] = int(z.Uint64())
+		}
+		return d
+	}
+}
+
+// floats returns a Generator of the sort.Float64Slice whose elements are
+// successive results of f.
+func floats(f func(r *rand.Rand) float64) Generator {
+	return func(n int, r *rand.Rand) sort.Interface {
+		d := make(sort.Float64Slice, n)
+		for i := range d {
+			d[i] = f(r)
+		}
+		return d
+	}
+}
+
+// Dataset identifies a reproducible input: the data produced by the cataloged
+// Generator called Name, when passed Size and a source seeded with Seed. The
+// catalog contains the datasets run by Analyze, the Bentley-McIlroy suite for
+// power-of-two values of m, and any Generators added with RegisterGenerator.
+type Dataset struct {
+	Name string
+	Size int
+	Seed int64
+}
+
+var generators = map[string]Generator{}
+
+// RegisterGenerator adds gen to the catalog under name, without adding it to
+// the datasets run by Analyze. Since the catalog is not synchronized,
+// RegisterGenerator should be called during program initialization.
+func RegisterGenerator(name string, gen Generator) {
+	generators[name] = gen
+}
+
+// LookupGenerator returns the cataloged Generator called name.
+func LookupGenerator(name string) (Generator, bool) {
+	for _, c := range cases {
+		if c.Name == name {
+			return c.Gen, true
+		}
+	}
+	if g, ok := generators[name]; ok {
+		return g, true
+	}
+	var m int
+	if i := strings.Index(name, " m="); i >= 0 {
+		if _, err := fmt.Sscan(name[i+3:], &m); err == nil && m > 0 {
+			for _, c := range BentleyMcIlroy(m/2 + 1) {
+				if c.Name == name {
+					return c.Gen, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// Generate returns the data identified by d. Generate panics if d.Name is not
+// in the catalog.
+func (d Dataset) Generate() sort.Interface {
+	g, ok := LookupGenerator(d.Name)
+	if !ok {
+		panic("sortutil: unknown dataset " + strconv.Quote(d.Name))
+	}
+	return g(d.Size, rand.New(rand.NewSource(d.Seed)))
+}
+
+// String returns the identifier of d, in the form "name:size:seed".
+func (d Dataset) String() string {
+	return fmt.Sprintf("%s:%d:%d", d.Name, d.Size, d.Seed)
+}
+
+// ParseDataset parses an identifier returned by Dataset.String, and verifies
+// that the named Generator is in the catalog.
+func ParseDataset(id string) (Dataset, error) {
+	var d Dataset
+	f := strings.Split(id, ":")
+	if len(f) < 3 {
+		return d, fmt.Errorf("sortutil: invalid dataset identifier %q", id)
+	}
+	k := len(f) - 2
+	d.Name = strings.Join(f[:k], ":")
+	size, err1 := strconv.Atoi(f[k])
+	seed, err2 := strconv.ParseInt(f[k+1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return d, fmt.Errorf("sortutil: invalid dataset identifier %q", id)
+	}
+	d.Size, d.Seed = size, seed
+	if _, ok := LookupGenerator(d.Name); !ok {
+		return d, fmt.Errorf("sortutil: unknown dataset %q", d.Name)
+	}
+	return d, nil
+}
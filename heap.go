@@ -0,0 +1,43 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// NewHeap combines data with push and pop functions into a heap.Interface, so
+// that heap operations may be logged or profiled by wrapping data. push must
+// append an element to data and pop must remove and return its last element;
+// data.Len must reflect both, as it does for a pointer to a slice type. For
+// example, to count calls per element, including elements pushed later:
+//
+//	s := sort.IntSlice{3, 1, 2}
+//	h := NewHeap(NewStat(&s), func(x interface{}) { s = append(s, x.(int)) },
+//		func() interface{} { x := s[len(s)-1]; s = s[:len(s)-1]; return x })
+func NewHeap(data sort.Interface, push func(x interface{}), pop func() interface{}) heap.Interface {
+	return heapAdapter{data, push, pop}
+}
+
+type heapAdapter struct {
+	sort.Interface
+	push func(x interface{})
+	pop  func() interface{}
+}
+
+func (h heapAdapter) Push(x interface{}) { h.push(x) }
+func (h heapAdapter) Pop() interface{}   { return h.pop() }
+
+// PopAllSorted establishes the heap invariant on h, then pops every element,
+// returning them in increasing order as defined by h's Less.
+func PopAllSorted(h heap.Interface) []interface{} {
+	heap.Init(h)
+	s := make([]interface{}, 0, h.Len())
+	for h.Len() > 0 {
+		s = append(s, heap.Pop(h))
+	}
+	return s
+}
@@ -0,0 +1,215 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import "sort"
+
+// Int8Slice attaches the methods of sort.Interface to []int8,
+// sorting in increasing order.
+type Int8Slice []int8
+
+func (s Int8Slice) Len() int           { return len(s) }
+func (s Int8Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Int8Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Int8Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Int8Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Int8Slice) Load(i int) interface{}        { return s[i] }
+func (s Int8Slice) Store(dst int, v interface{})  { s[dst] = v.(int8) }
+func (Int8Slice) LessValue(a, b interface{}) bool { return a.(int8) < b.(int8) }
+
+func (s Int8Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Int8Slice)[j]
+}
+
+func (s Int8Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// Int16Slice attaches the methods of sort.Interface to []int16,
+// sorting in increasing order.
+type Int16Slice []int16
+
+func (s Int16Slice) Len() int           { return len(s) }
+func (s Int16Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Int16Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Int16Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Int16Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Int16Slice) Load(i int) interface{}        { return s[i] }
+func (s Int16Slice) Store(dst int, v interface{})  { s[dst] = v.(int16) }
+func (Int16Slice) LessValue(a, b interface{}) bool { return a.(int16) < b.(int16) }
+
+func (s Int16Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Int16Slice)[j]
+}
+
+func (s Int16Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// Int32Slice attaches the methods of sort.Interface to []int32,
+// sorting in increasing order.
+type Int32Slice []int32
+
+func (s Int32Slice) Len() int           { return len(s) }
+func (s Int32Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Int32Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Int32Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Int32Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Int32Slice) Load(i int) interface{}        { return s[i] }
+func (s Int32Slice) Store(dst int, v interface{})  { s[dst] = v.(int32) }
+func (Int32Slice) LessValue(a, b interface{}) bool { return a.(int32) < b.(int32) }
+
+func (s Int32Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Int32Slice)[j]
+}
+
+func (s Int32Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// Int64Slice attaches the methods of sort.Interface to []int64,
+// sorting in increasing order.
+type Int64Slice []int64
+
+func (s Int64Slice) Len() int           { return len(s) }
+func (s Int64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Int64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Int64Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Int64Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Int64Slice) Load(i int) interface{}        { return s[i] }
+func (s Int64Slice) Store(dst int, v interface{})  { s[dst] = v.(int64) }
+func (Int64Slice) LessValue(a, b interface{}) bool { return a.(int64) < b.(int64) }
+
+func (s Int64Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Int64Slice)[j]
+}
+
+func (s Int64Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// Uint16Slice attaches the methods of sort.Interface to []uint16,
+// sorting in increasing order.
+type Uint16Slice []uint16
+
+func (s Uint16Slice) Len() int           { return len(s) }
+func (s Uint16Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Uint16Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Uint16Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Uint16Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Uint16Slice) Load(i int) interface{}        { return s[i] }
+func (s Uint16Slice) Store(dst int, v interface{})  { s[dst] = v.(uint16) }
+func (Uint16Slice) LessValue(a, b interface{}) bool { return a.(uint16) < b.(uint16) }
+
+func (s Uint16Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Uint16Slice)[j]
+}
+
+func (s Uint16Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// Uint32Slice attaches the methods of sort.Interface to []uint32,
+// sorting in increasing order.
+type Uint32Slice []uint32
+
+func (s Uint32Slice) Len() int           { return len(s) }
+func (s Uint32Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Uint32Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Uint32Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Uint32Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Uint32Slice) Load(i int) interface{}        { return s[i] }
+func (s Uint32Slice) Store(dst int, v interface{})  { s[dst] = v.(uint32) }
+func (Uint32Slice) LessValue(a, b interface{}) bool { return a.(uint32) < b.(uint32) }
+
+func (s Uint32Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Uint32Slice)[j]
+}
+
+func (s Uint32Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// Uint64Slice attaches the methods of sort.Interface to []uint64,
+// sorting in increasing order.
+type Uint64Slice []uint64
+
+func (s Uint64Slice) Len() int           { return len(s) }
+func (s Uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s Uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s Uint64Slice) Move(dst, src int)             { s[dst] = s[src] }
+func (s Uint64Slice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s Uint64Slice) Load(i int) interface{}        { return s[i] }
+func (s Uint64Slice) Store(dst int, v interface{})  { s[dst] = v.(uint64) }
+func (Uint64Slice) LessValue(a, b interface{}) bool { return a.(uint64) < b.(uint64) }
+
+func (s Uint64Slice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(Uint64Slice)[j]
+}
+
+func (s Uint64Slice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// UintptrSlice attaches the methods of sort.Interface to []uintptr,
+// sorting in increasing order.
+type UintptrSlice []uintptr
+
+func (s UintptrSlice) Len() int           { return len(s) }
+func (s UintptrSlice) Less(i, j int) bool { return s[i] < s[j] }
+func (s UintptrSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s UintptrSlice) Move(dst, src int)             { s[dst] = s[src] }
+func (s UintptrSlice) Swap3(i, j, k int)             { s[i], s[j], s[k] = s[k], s[i], s[j] }
+func (s UintptrSlice) Load(i int) interface{}        { return s[i] }
+func (s UintptrSlice) Store(dst int, v interface{})  { s[dst] = v.(uintptr) }
+func (UintptrSlice) LessValue(a, b interface{}) bool { return a.(uintptr) < b.(uintptr) }
+
+func (s UintptrSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return s[i] < other.(UintptrSlice)[j]
+}
+
+func (s UintptrSlice) Snapshot() []uint64 {
+	fp := make([]uint64, len(s))
+	for i, v := range s {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
@@ -0,0 +1,165 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"sort"
+	"strings"
+)
+
+// NaturalStringSlice attaches the methods of sort.Interface to []string,
+// sorting in increasing order, except that runs of ASCII digits compare by
+// numeric value, so that "file2" precedes "file10". Strings that differ only
+// in leading zeros are ordered bytewise.
+type NaturalStringSlice []string
+
+func (n NaturalStringSlice) Len() int           { return len(n) }
+func (n NaturalStringSlice) Less(i, j int) bool { return compareNatural(n[i], n[j]) < 0 }
+func (n NaturalStringSlice) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+func (n NaturalStringSlice) Move(dst, src int)            { n[dst] = n[src] }
+func (n NaturalStringSlice) Load(i int) interface{}       { return n[i] }
+func (n NaturalStringSlice) Store(dst int, v interface{}) { n[dst] = v.(string) }
+func (NaturalStringSlice) LessValue(a, b interface{}) bool {
+	return compareNatural(a.(string), b.(string)) < 0
+}
+func (n NaturalStringSlice) Swap3(i, j, k int) { n[i], n[j], n[k] = n[k], n[i], n[j] }
+
+func (n NaturalStringSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return compareNatural(n[i], other.(NaturalStringSlice)[j]) < 0
+}
+
+func compareNatural(a, b string) int {
+	x, y := a, b
+	for x != "" && y != "" {
+		if isDigit(x[0]) && isDigit(y[0]) {
+			var p, q string
+			p, x = digits(x)
+			q, y = digits(y)
+			if c := compareDigits(p, q); c != 0 {
+				return c
+			}
+			continue
+		}
+		if x[0] != y[0] {
+			return int(x[0]) - int(y[0])
+		}
+		x, y = x[1:], y[1:]
+	}
+	if x != "" || y != "" {
+		return len(x) - len(y)
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// digits splits s after its leading run of digits.
+func digits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareDigits compares two runs of digits by numeric value.
+func compareDigits(p, q string) int {
+	p, q = strings.TrimLeft(p, "0"), strings.TrimLeft(q, "0")
+	if len(p) != len(q) {
+		return len(p) - len(q)
+	}
+	return strings.Compare(p, q)
+}
+
+// VersionSlice attaches the methods of sort.Interface to []string, sorting
+// semantic-version-like strings such as "v1.10.0-rc.1" in increasing order
+// of precedence. A leading "v" and any "+build" suffix are ignored, missing
+// components count as zero, and a pre-release precedes its release.
+// Versions of equal precedence are ordered bytewise.
+type VersionSlice []string
+
+func (v VersionSlice) Len() int           { return len(v) }
+func (v VersionSlice) Less(i, j int) bool { return compareVersion(v[i], v[j]) < 0 }
+func (v VersionSlice) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+func (v VersionSlice) Move(dst, src int)            { v[dst] = v[src] }
+func (v VersionSlice) Load(i int) interface{}       { return v[i] }
+func (v VersionSlice) Store(dst int, x interface{}) { v[dst] = x.(string) }
+func (VersionSlice) LessValue(a, b interface{}) bool {
+	return compareVersion(a.(string), b.(string)) < 0
+}
+func (v VersionSlice) Swap3(i, j, k int) { v[i], v[j], v[k] = v[k], v[i], v[j] }
+
+func (v VersionSlice) CrossLess(i int, other sort.Interface, j int) bool {
+	return compareVersion(v[i], other.(VersionSlice)[j]) < 0
+}
+
+func compareVersion(a, b string) int {
+	x, xpre := splitVersion(a)
+	y, ypre := splitVersion(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		p, q := "0", "0"
+		if i < len(x) {
+			p = x[i]
+		}
+		if i < len(y) {
+			q = y[i]
+		}
+		if c := compareIdent(p, q); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case xpre == nil && ypre != nil:
+		return 1
+	case xpre != nil && ypre == nil:
+		return -1
+	}
+	for i := 0; i < len(xpre) && i < len(ypre); i++ {
+		if c := compareIdent(xpre[i], ypre[i]); c != 0 {
+			return c
+		}
+	}
+	if len(xpre) != len(ypre) {
+		return len(xpre) - len(ypre)
+	}
+	return strings.Compare(a, b)
+}
+
+// splitVersion returns the dot-separated release and pre-release identifiers
+// of v. The pre-release identifiers are nil if v is not a pre-release.
+func splitVersion(v string) (rel, pre []string) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		pre = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+	return strings.Split(v, "."), pre
+}
+
+// compareIdent compares version identifiers as semver does: numerically if
+// both are numeric, with numeric identifiers preceding others, and bytewise
+// otherwise.
+func compareIdent(p, q string) int {
+	pn, qn := isNumeric(p), isNumeric(q)
+	switch {
+	case pn && qn:
+		return compareDigits(p, q)
+	case pn:
+		return -1
+	case qn:
+		return 1
+	}
+	return strings.Compare(p, q)
+}
+
+func isNumeric(s string) bool {
+	d, rest := digits(s)
+	return d != "" && rest == ""
+}
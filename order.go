@@ -0,0 +1,116 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"cmp"
+	"reflect"
+	"sort"
+)
+
+// Ordering is a sort.Interface over a slice of records, ordered by a list of
+// keys, each ascending or descending. Ties on every key leave records in an
+// unspecified relative order under Sort, or their original order under Stable.
+//
+//	OrderBy(people).Asc(Key(byName)).Desc(Key(byAge)).Sort()
+type Ordering[T any] struct {
+	items     []T
+	keys      []func(a, b T) int
+	nilsFirst bool
+	nilable   bool // whether T has nil values
+}
+
+// OrderBy returns an Ordering of items with no keys; nil items sort last.
+func OrderBy[T any](items []T) *Ordering[T] {
+	o := &Ordering[T]{items: items}
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		o.nilable = true
+	}
+	return o
+}
+
+// Key returns a comparison function ordering records by the key f extracts,
+// for use with Asc and Desc.
+func Key[T any, K cmp.Ordered](f func(T) K) func(a, b T) int {
+	return func(a, b T) int { return cmp.Compare(f(a), f(b)) }
+}
+
+// Asc appends a key to o, comparing records with c in ascending order.
+func (o *Ordering[T]) Asc(c func(a, b T) int) *Ordering[T] {
+	o.keys = append(o.keys, c)
+	return o
+}
+
+// Desc appends a key to o, comparing records with c in descending order.
+func (o *Ordering[T]) Desc(c func(a, b T) int) *Ordering[T] {
+	o.keys = append(o.keys, func(a, b T) int { return c(b, a) })
+	return o
+}
+
+// NilsFirst causes nil items to sort before all others, rather than after.
+// Keys are never called with nil items.
+func (o *Ordering[T]) NilsFirst() *Ordering[T] {
+	o.nilsFirst = true
+	return o
+}
+
+// Sort sorts the items by o's keys.
+func (o *Ordering[T]) Sort() { sort.Sort(o) }
+
+// Stable sorts the items by o's keys, keeping equal items in their original
+// order.
+func (o *Ordering[T]) Stable() { sort.Stable(o) }
+
+func (o *Ordering[T]) Len() int      { return len(o.items) }
+func (o *Ordering[T]) Swap(i, j int) { o.items[i], o.items[j] = o.items[j], o.items[i] }
+
+func (o *Ordering[T]) Less(i, j int) bool {
+	if o.nilable {
+		if na, nb := o.isNil(i), o.isNil(j); na || nb {
+			return na != nb && na == o.nilsFirst
+		}
+	}
+	a, b := o.items[i], o.items[j]
+	for _, c := range o.keys {
+		if r := c(a, b); r != 0 {
+			return r < 0
+		}
+	}
+	return false
+}
+
+// isNil reports whether item i is nil. It reflects on a pointer to the item,
+// rather than the item itself, so that the item is not boxed.
+func (o *Ordering[T]) isNil(i int) bool {
+	return reflect.ValueOf(&o.items[i]).Elem().IsNil()
+}
+
+// KV is a map entry, as returned by SortedByValue.
+type KV[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// SortedKeys returns the keys of m, ordered by less.
+func SortedKeys[K comparable, V any](m map[K]V, less func(a, b K) bool) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Sort(Any(keys, func(i, j int) bool { return less(keys[i], keys[j]) }))
+	return keys
+}
+
+// SortedByValue returns the entries of m, ordered by less applied to their
+// values. Entries with equal values are returned in an unspecified order.
+func SortedByValue[K comparable, V any](m map[K]V, less func(a, b V) bool) []KV[K, V] {
+	kvs := make([]KV[K, V], 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, KV[K, V]{k, v})
+	}
+	sort.Sort(Any(kvs, func(i, j int) bool { return less(kvs[i].Value, kvs[j].Value) }))
+	return kvs
+}
@@ -0,0 +1,235 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Permutation is an arrangement of the integers [0,n). When describing a
+// rearrangement of data, p[i] is the original index of the element now at
+// index i. Permutation implements sort.Interface, so the rearrangement made by
+// a sort can be recorded by proxying an identity Permutation:
+//
+//	p := NewPermutation(data.Len())
+//	sort.Sort(NewProxy(data, p))
+type Permutation []int
+
+// NewPermutation returns the identity Permutation of length n.
+func NewPermutation(n int) Permutation {
+	return Permutation(NewIntSeq(n))
+}
+
+func (p Permutation) Len() int           { return len(p) }
+func (p Permutation) Less(i, j int) bool { return p[i] < p[j] }
+func (p Permutation) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (p Permutation) Move(dst, src int)             { p[dst] = p[src] }
+func (p Permutation) Load(i int) interface{}        { return p[i] }
+func (p Permutation) Store(dst int, v interface{})  { p[dst] = v.(int) }
+func (Permutation) LessValue(a, b interface{}) bool { return a.(int) < b.(int) }
+func (p Permutation) Swap3(i, j, k int)             { p[i], p[j], p[k] = p[k], p[i], p[j] }
+
+func (p Permutation) CrossLess(i int, other sort.Interface, j int) bool {
+	return p[i] < other.(Permutation)[j]
+}
+
+// Snapshot returns the value of each element, for use with Audit.
+func (p Permutation) Snapshot() []uint64 {
+	fp := make([]uint64, len(p))
+	for i, v := range p {
+		fp[i] = uint64(v)
+	}
+	return fp
+}
+
+// PermRank returns the index of p among all permutations of the same length
+// in lexicographic order, from zero for the identity to n!-1 for the reversed
+// identity. PermRank(Permutation(e.Input)) concisely identifies the input of
+// a *VerifyError returned by VerifyAll.
+func PermRank(p Permutation) *big.Int {
+	r := new(big.Int)
+	f := new(big.Int)
+	for i, v := range p {
+		// the Lehmer code digit is the count of smaller values to the right
+		d := 0
+		for _, w := range p[i+1:] {
+			if w < v {
+				d++
+			}
+		}
+		f.MulRange(1, int64(len(p)-1-i))
+		r.Add(r, f.Mul(f, big.NewInt(int64(d))))
+	}
+	return r
+}
+
+// PermUnrank returns the permutation of length n whose rank, as returned by
+// PermRank, is rank. PermUnrank will panic unless 0 <= rank < n!.
+func PermUnrank(n int, rank *big.Int) Permutation {
+	if rank.Sign() < 0 || rank.Cmp(new(big.Int).MulRange(1, int64(n))) >= 0 {
+		panic(panicmsg)
+	}
+	rest := NewIntSeq(n)
+	p := make(Permutation, n)
+	r := new(big.Int).Set(rank)
+	f, d := new(big.Int), new(big.Int)
+	for i := range p {
+		f.MulRange(1, int64(n-1-i))
+		r.DivMod(r, f, d)
+		k := int(r.Int64())
+		p[i] = rest[k]
+		rest = append(rest[:k], rest[k+1:]...)
+		r, d = d, r
+	}
+	return p
+}
+
+// Parity returns 0 if p is an even permutation, or 1 if it is odd: that is,
+// the parity of the number of swaps that produce p from the identity. Since
+// any rearrangement through swaps has the parity of the resulting
+// permutation, comparing Parity against (*Stat).Parity can reveal swaps that
+// were lost or duplicated, such as by a faulty proxy.
+func Parity(p Permutation) int {
+	seen := make([]bool, len(p))
+	cycles := 0
+	for i := range p {
+		if !seen[i] {
+			cycles++
+			for j := i; !seen[j]; j = p[j] {
+				seen[j] = true
+			}
+		}
+	}
+	return (len(p) - cycles) % 2
+}
+
+// MatchOrder rearranges data into the order described by ref, so that the
+// element at each index i is the element formerly at index ref[i]. If ref
+// was recorded by proxying a sort of other data of the same length, data is
+// then ordered as that sort ordered the other data. MatchOrder makes at most
+// one Swap per element, or fewer Swap3 calls if data implements Swapper3.
+// MatchOrder will panic unless ref is a permutation of [0,data.Len()).
+func MatchOrder(data sort.Interface, ref Permutation) {
+	checkRef(ref, data.Len())
+	apply(data, ref)
+}
+
+// ApplySwaps is like MatchOrder, but only calls Swap, making the minimal
+// number of swaps: the length of ref less the number of its cycles.
+func ApplySwaps(data sort.Interface, ref Permutation) {
+	checkRef(ref, data.Len())
+	done := make([]bool, len(ref))
+	for i := range ref {
+		for j := i; !done[j]; j = ref[j] {
+			done[j] = true
+			if ref[j] != i {
+				data.Swap(j, ref[j])
+			}
+		}
+	}
+}
+
+// ApplyMoves is like MatchOrder, but copies each misplaced element directly
+// into place, with one Load and Store per cycle, so that each element is
+// written once rather than two or three times as by a swap. This suits
+// containers of large elements. Every built-in container implements
+// MoveInterface.
+func ApplyMoves(data MoveInterface, ref Permutation) {
+	checkRef(ref, data.Len())
+	done := make([]bool, len(ref))
+	for i := range ref {
+		if done[i] || ref[i] == i {
+			continue
+		}
+		v := data.Load(i)
+		j := i
+		for ; ref[j] != i; j = ref[j] {
+			done[j] = true
+			data.Move(j, ref[j])
+		}
+		done[j] = true
+		data.Store(j, v)
+	}
+}
+
+// Cycles returns the cycle decomposition of p, including fixed points as
+// cycles of one element. Each cycle lists indices i, p[i], p[p[i]], and so
+// on, starting from its least index, and cycles are ordered by least index.
+func Cycles(p Permutation) [][]int {
+	var c [][]int
+	done := make([]bool, len(p))
+	for i := range p {
+		var cycle []int
+		for j := i; !done[j]; j = p[j] {
+			done[j] = true
+			cycle = append(cycle, j)
+		}
+		if cycle != nil {
+			c = append(c, cycle)
+		}
+	}
+	return c
+}
+
+// checkRef panics unless ref is a permutation of [0,n).
+func checkRef(ref Permutation, n int) {
+	if len(ref) != n {
+		panic(panicmsg)
+	}
+	seen := make([]bool, n)
+	for _, v := range ref {
+		if v < 0 || v >= n || seen[v] {
+			panic("sortutil: ref is not a permutation")
+		}
+		seen[v] = true
+	}
+}
+
+// Footrule returns Spearman's footrule distance between the orderings a and
+// b: the sum, over every element, of the distance between its indices in a
+// and in b. It ranges from 0, when a and b are identical, to about n²/2.
+// Footrule will panic unless a and b have the same length.
+func Footrule(a, b Permutation) int {
+	d := 0
+	for _, v := range rankDiffs(a, b) {
+		if v < 0 {
+			v = -v
+		}
+		d += v
+	}
+	return d
+}
+
+// Spearman returns Spearman's rank correlation coefficient between the
+// orderings a and b, from 1, when they are identical, to -1, when one is the
+// reverse of the other. Orderings of fewer than two elements are identical.
+// Spearman will panic unless a and b have the same length.
+func Spearman(a, b Permutation) float64 {
+	n := float64(len(a))
+	if n < 2 {
+		return 1
+	}
+	var ss float64
+	for _, v := range rankDiffs(a, b) {
+		ss += float64(v * v)
+	}
+	return 1 - 6*ss/(n*(n*n-1))
+}
+
+// rankDiffs returns, for each element x, the index of x in a less its index
+// in b.
+func rankDiffs(a, b Permutation) []int {
+	if len(a) != len(b) {
+		panic(panicmsg)
+	}
+	d := make([]int, len(a))
+	for i := range a {
+		d[a[i]] += i
+		d[b[i]] -= i
+	}
+	return d
+}
@@ -0,0 +1,149 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ByteKeyer is implemented by containers whose order is that of a byte-string
+// key per element, compared as by bytes.Compare.
+type ByteKeyer interface {
+	Key(i int) []byte
+}
+
+// ByteKeyed is a sort.Interface that also implements ByteKeyer.
+type ByteKeyed interface {
+	sort.Interface
+	ByteKeyer
+}
+
+// RadixSort sorts data by its keys using a most-significant-digit radix sort
+// (American flag sort), which examines only as many leading bytes of each key
+// as are needed to distinguish it, and moves elements by Swap alone. Less is
+// never called. RadixSort is not stable; see RadixSortLSD.
+func RadixSort(data ByteKeyed) {
+	msd(data, 0, data.Len(), 0)
+}
+
+// keyByte returns the bucket of the element at i for the byte at depth d:
+// zero if the key has ended, otherwise one more than the byte.
+func keyByte(data ByteKeyer, i, d int) int {
+	if k := data.Key(i); d < len(k) {
+		return int(k[d]) + 1
+	}
+	return 0
+}
+
+func msd(data ByteKeyed, lo, hi, d int) {
+	if hi-lo < 2 {
+		return
+	}
+	end := distribute(data, lo, hi, 257, func(i int) int { return keyByte(data, i, d) })
+	// keys in bucket zero have ended, and are equal
+	for b := 1; b < len(end); b++ {
+		if end[b]-end[b-1] > 1 {
+			msd(data, end[b-1], end[b], d+1)
+		}
+	}
+}
+
+// distribute rearranges data[lo:hi] by Swap so that elements are grouped by
+// increasing bucket, and returns the end index of each bucket's region. It
+// panics if bucket returns a value outside [0,n).
+func distribute(data sort.Interface, lo, hi, n int, bucket func(i int) int) []int {
+	count := make([]int, n)
+	for i := lo; i < hi; i++ {
+		b := bucket(i)
+		if b < 0 || b >= n {
+			panic(fmt.Sprintf("sortutil: bucket %d out of range [0,%d)", b, n))
+		}
+		count[b]++
+	}
+	next, end := count, make([]int, n)
+	for b, s := 0, lo; b < n; b++ {
+		s += count[b]
+		next[b], end[b] = s-count[b], s
+	}
+	// place each element into its bucket by following cycles
+	for b := range next {
+		for next[b] < end[b] {
+			i := next[b]
+			if c := bucket(i); c == b {
+				next[b]++
+			} else {
+				data.Swap(i, next[c])
+				next[c]++
+			}
+		}
+	}
+	return end
+}
+
+// BucketSort distributes data by Swap into regions by increasing bucket, as
+// reported by bucket for the element currently at each index, then calls
+// inner on a Sub view of each region of more than one element. The result is
+// sorted if bucket is monotonic with respect to Less. BucketSort will panic
+// if bucket returns a value outside [0,nBuckets).
+func BucketSort(data sort.Interface, bucket func(i int) int, nBuckets int, inner func(sort.Interface)) {
+	end := distribute(data, 0, data.Len(), nBuckets, bucket)
+	for b, lo := 0, 0; b < nBuckets; b++ {
+		if end[b]-lo > 1 {
+			inner(NewSub(data, lo, end[b]))
+		}
+		lo = end[b]
+	}
+}
+
+// RadixSortLSD sorts data by its keys using a stable least-significant-digit
+// radix sort, which makes one pass per byte of the longest key. Each pass
+// computes a stable counting sort of the indices and applies it with Swap.
+// Less is never called.
+func RadixSortLSD(data ByteKeyed) {
+	n := data.Len()
+	max := 0
+	for i := 0; i < n; i++ {
+		if l := len(data.Key(i)); l > max {
+			max = l
+		}
+	}
+	p := make([]int, n)
+	for d := max - 1; d >= 0; d-- {
+		var pos [257]int
+		for i := 0; i < n; i++ {
+			pos[keyByte(data, i, d)]++
+		}
+		for b, s := 0, 0; b < len(pos); b++ {
+			pos[b], s = s, s+pos[b]
+		}
+		for i := 0; i < n; i++ {
+			b := keyByte(data, i, d)
+			p[pos[b]] = i
+			pos[b]++
+		}
+		apply(data, p)
+	}
+}
+
+// IntKeyer is implemented by containers whose order is that of a small
+// non-negative integer key per element.
+type IntKeyer interface {
+	IntKey(i int) int
+}
+
+// IntKeyed is a sort.Interface that also implements IntKeyer.
+type IntKeyed interface {
+	sort.Interface
+	IntKeyer
+}
+
+// CountingSort sorts data by its keys, which must lie in [0,maxKey], in
+// O(n+maxKey) time, by counting the elements with each key and then placing
+// each element by following cycles of Swaps. Less is never called, and the
+// sort is not stable. CountingSort will panic if a key is out of range.
+func CountingSort(data IntKeyed, maxKey int) {
+	distribute(data, 0, data.Len(), maxKey+1, data.IntKey)
+}
@@ -0,0 +1,422 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes r to w as an indented JSON document.
+func (r Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(r)
+}
+
+var csvHeader = []string{
+	"alg", "name", "id", "len", "seed", "runs", "ok", "ratio", "warning",
+	"calls_len", "calls_less", "calls_swap",
+	"less_min", "less_max", "less_mean", "less_std",
+	"swap_min", "swap_max", "swap_mean", "swap_std",
+	"duration_ns", "allocs", "ns_per_op", "err",
+}
+
+// WriteCSV writes r to w as CSV, one record per Result following a header
+// record. Traces are omitted.
+func (r Report) WriteCSV(w io.Writer) error {
+	c := csv.NewWriter(w)
+	c.Write(csvHeader)
+	for _, v := range r.Results {
+		rec := []string{
+			v.Alg, v.Name, v.ID, strconv.Itoa(v.Len), strconv.FormatInt(v.Seed, 10), strconv.Itoa(v.Runs),
+			strconv.FormatBool(v.OK), strconv.FormatFloat(v.Ratio, 'g', -1, 64), v.Warning,
+			strconv.FormatInt(v.Calls.Len, 10), strconv.FormatInt(v.Calls.Less, 10), strconv.FormatInt(v.Calls.Swap, 10),
+		}
+		for _, a := range v.Agg {
+			rec = append(rec, strconv.FormatInt(a.Min, 10), strconv.FormatInt(a.Max, 10), fmt.Sprint(a.Mean), fmt.Sprint(a.Std))
+		}
+		rec = append(rec, strconv.FormatInt(int64(v.Duration), 10),
+			strconv.FormatUint(v.Allocs, 10), strconv.FormatInt(v.NsPerOp, 10), v.Err)
+		c.Write(rec)
+	}
+	c.Flush()
+	return c.Error()
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Class   string        `xml:"classname,attr"`
+	Name    string        `xml:"name,attr"`
+	Time    float64       `xml:"time,attr"`
+	Failure *junitFailure `xml:"failure"`
+	Out     string        `xml:"system-out"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes r to w as a JUnit XML test suite named suite, with one
+// test case per Result. Failures carry the error as their message, and the
+// call count summary and trace as their body.
+func (r Report) WriteJUnit(w io.Writer, suite string) error {
+	s := junitSuite{Name: suite, Tests: len(r.Results)}
+	for _, v := range r.Results {
+		c := junitCase{Class: suite, Name: v.title(), Time: v.Duration.Seconds(), Out: v.stats()}
+		if v.Alg != "" {
+			c.Class, c.Name = suite+"."+v.Alg, v.Name
+		}
+		if !v.OK {
+			s.Failures++
+			c.Failure = &junitFailure{v.Err, v.stats() + "\n\n" + v.Trace}
+		}
+		s.Time += c.Time
+		s.Cases = append(s.Cases, c)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "\t")
+	if err := e.Encode(s); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP writes r to w in the Test Anything Protocol, version 13, with one
+// test point per Result. Failed test points are followed by a YAML block
+// containing the error and call count summary.
+func (r Report) WriteTAP(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(r.Results))
+	for i, v := range r.Results {
+		if v.OK {
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, v.title())
+			continue
+		}
+		fmt.Fprintf(&b, "not ok %d - %s\n  ---\n  message: %s\n  stats: |\n", i+1, v.title(), strconv.Quote(v.Err))
+		for _, line := range strings.Split(v.stats(), "\n") {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+		b.WriteString("  ...\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sparkWidth is the maximum number of points in a rendered sparkline.
+const sparkWidth = 64
+
+// downsample reduces v to at most width values, each the maximum of a
+// contiguous group of values in v.
+func downsample(v []int64, width int) []int64 {
+	if len(v) <= width {
+		return v
+	}
+	r := make([]int64, width)
+	for i := range r {
+		for _, x := range v[i*len(v)/width : (i+1)*len(v)/width] {
+			if x > r[i] {
+				r[i] = x
+			}
+		}
+	}
+	return r
+}
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// spark renders v as a line of Unicode block characters, scaled so that the
+// largest value is a full block.
+func spark(v []int64) string {
+	v = downsample(v, sparkWidth)
+	max := int64(0)
+	for _, x := range v {
+		if x > max {
+			max = x
+		}
+	}
+	return string(blocks(v, 0, max))
+}
+
+// Spark renders each value of v as a Unicode block character, scaled so that
+// the least value is the lowest block and the greatest is a full block, to
+// show the shape of the data at a glance.
+func Spark(v []int) string {
+	if len(v) == 0 {
+		return ""
+	}
+	w := make([]int64, len(v))
+	for i, x := range v {
+		w[i] = int64(x)
+	}
+	min, max := w[0], w[0]
+	for _, x := range w {
+		if x < min {
+			min = x
+		}
+		if x > max {
+			max = x
+		}
+	}
+	return string(blocks(w, min, max))
+}
+
+// blocks returns a block character for each value of v, scaled from min to
+// max.
+func blocks(v []int64, min, max int64) []rune {
+	r := make([]rune, len(v))
+	for i, x := range v {
+		r[i] = sparkRunes[0]
+		if max > min {
+			r[i] = sparkRunes[(x-min)*int64(len(sparkRunes)-1)/(max-min)]
+		}
+	}
+	return r
+}
+
+// elems returns the per-element Less and Swap counts of v as separate slices.
+func (v Result) elems() (less, swap []int64) {
+	less, swap = make([]int64, len(v.Elems)), make([]int64, len(v.Elems))
+	for i, e := range v.Elems {
+		less[i], swap[i] = e.Less, e.Swap
+	}
+	return
+}
+
+// status returns a short description of whether v succeeded.
+func (v Result) status() string {
+	if v.OK {
+		return "ok"
+	}
+	return "FAIL"
+}
+
+// WriteMarkdown writes r to w as a Markdown document containing a table of
+// results, with sparklines of the per-element Less and Swap counts, followed
+// by the errors and traces of any failed runs.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Algorithm | Dataset | Len | Result | Less | Swap | Time | Less/elem | Swap/elem |\n")
+	b.WriteString("|---|---|--:|---|--:|--:|--:|---|---|\n")
+	for _, v := range r.Results {
+		less, swap := v.elems()
+		fmt.Fprintf(&b, "| %s | %s | %d | %s | %d | %d | %v | %s | %s |\n",
+			v.Alg, v.Name, v.Len, v.status(), v.Calls.Less, v.Calls.Swap, v.Duration, spark(less), spark(swap))
+	}
+	for _, v := range r.Results {
+		if !v.OK {
+			fmt.Fprintf(&b, "\n## %s\n\n%s\n\n```\n%s%s\n```\n", v.title(), v.Err, v.Trace, v.stats())
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sparkPoints returns SVG polyline points plotting v in a 100x20 box.
+func sparkPoints(v []int64) string {
+	v = downsample(v, sparkWidth)
+	max := int64(1)
+	for _, x := range v {
+		if x > max {
+			max = x
+		}
+	}
+	var b strings.Builder
+	for i, x := range v {
+		fmt.Fprintf(&b, "%d,%d ", i*100/len(v), 20-x*20/max)
+	}
+	return b.String()
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"points": sparkPoints,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: right; }
+.FAIL { background: #fcc; }
+polyline { fill: none; stroke: #36c; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Algorithm</th><th>Dataset</th><th>Len</th><th>Result</th><th>Less</th><th>Swap</th><th>Time</th><th>Less/elem</th><th>Swap/elem</th></tr>
+{{range .Results}}<tr class="{{.Status}}"><td>{{.Alg}}</td><td>{{.Name}}</td><td>{{.Len}}</td><td>{{.Status}}</td><td>{{.Calls.Less}}</td><td>{{.Calls.Swap}}</td><td>{{.Duration}}</td><td><svg width="100" height="20"><polyline points="{{points .Less}}"/></svg></td><td><svg width="100" height="20"><polyline points="{{points .Swap}}"/></svg></td></tr>
+{{end}}</table>
+{{range .Results}}{{if not .OK}}<h2>{{.Title}}</h2>
+<p>{{.Err}}</p>
+<pre>{{.Trace}}{{.Stats}}</pre>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes r to w as a standalone HTML document with the given title,
+// containing the same information as WriteMarkdown, with the per-element
+// counts drawn as inline SVG charts.
+func (r Report) WriteHTML(w io.Writer, title string) error {
+	type result struct {
+		Result
+		Title, Status, Stats string
+		Less, Swap           []int64
+	}
+	data := struct {
+		Title   string
+		Results []result
+	}{Title: title}
+	for _, v := range r.Results {
+		less, swap := v.elems()
+		data.Results = append(data.Results, result{v, v.title(), v.status(), v.stats(), less, swap})
+	}
+	return htmlReport.Execute(w, data)
+}
+
+var htmlTrace = template.Must(template.New("trace").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+#bars { display: flex; align-items: flex-end; height: 300px; gap: 1px; }
+#bars div { flex: 1; background: #36c; }
+#bars .less { background: #fa0; }
+#bars .swap { background: #c33; }
+#op { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div id="bars"></div>
+<p>
+<button id="back">&lt;</button>
+<button id="play">play</button>
+<button id="fwd">&gt;</button>
+<input id="step" type="range" min="0" value="0">
+<label>speed <input id="speed" type="range" min="1" max="1000" value="50"></label>
+</p>
+<p id="op"></p>
+<script>
+var ops = {{.Ops}}, v = {{.Values}}.slice(), k = 0, timer = null;
+var max = Math.max.apply(null, v.concat([1])), bars = document.getElementById("bars");
+var step = document.getElementById("step"), speed = document.getElementById("speed");
+step.max = ops.length;
+for (var i = 0; i < v.length; i++) {
+	bars.appendChild(document.createElement("div"));
+}
+function swap(o) {
+	if (o[0] == 2) {
+		var t = v[o[1]]; v[o[1]] = v[o[2]]; v[o[2]] = t;
+	}
+}
+function draw() {
+	var o = k > 0 ? ops[k-1] : null;
+	for (var i = 0; i < v.length; i++) {
+		var b = bars.children[i];
+		b.style.height = (100 * (v[i] + 1) / (max + 1)) + "%";
+		b.className = o && o[0] > 0 && (o[1] == i || o[2] == i) ? (o[0] == 1 ? "less" : "swap") : "";
+	}
+	step.value = k;
+	var names = ["Len", "Less", "Swap"];
+	document.getElementById("op").textContent = k + "/" + ops.length + (o ?
+		": " + names[o[0]] + (o[0] == 0 ? " " + o[1] : "(" + o[1] + ", " + o[2] + ")" + (o[0] == 1 ? " " + !!o[3] : "")) : "");
+}
+function seek(n) {
+	n = Math.max(0, Math.min(ops.length, n));
+	while (k < n) swap(ops[k++]);
+	while (k > n) swap(ops[--k]);
+	draw();
+}
+function stop() {
+	clearInterval(timer);
+	timer = null;
+	document.getElementById("play").textContent = "play";
+}
+function play() {
+	if (timer) return stop();
+	if (k == ops.length) seek(0);
+	document.getElementById("play").textContent = "pause";
+	timer = setInterval(function() {
+		if (k == ops.length) return stop();
+		seek(k + 1);
+	}, 1000 / speed.value);
+}
+document.getElementById("back").onclick = function() { stop(); seek(k - 1); };
+document.getElementById("fwd").onclick = function() { stop(); seek(k + 1); };
+document.getElementById("play").onclick = play;
+speed.oninput = function() { if (timer) { stop(); play(); } };
+step.oninput = function() { stop(); seek(+step.value); };
+draw();
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes t to w as a standalone HTML document with the given title,
+// containing a player that steps through the calls of t, drawing the elements
+// as bars and highlighting the indices of each call. The initial element
+// values are taken from values, or if values is nil, from the final position
+// of each element, as if t left the data sorted.
+func (t Trace) WriteHTML(w io.Writer, title string, values []int) error {
+	n := len(values)
+	for _, o := range t {
+		switch {
+		case values != nil:
+		case o.Kind == OpLen:
+			n = max(n, o.I)
+		default:
+			n = max(n, o.I+1, o.J+1)
+		}
+	}
+	if values == nil {
+		p := NewIntSeq(n)
+		for _, o := range t {
+			if o.Kind == OpSwap {
+				p.Swap(o.I, o.J)
+			}
+		}
+		values = make([]int, n)
+		for k, i := range p {
+			values[i] = k
+		}
+	}
+	data := struct {
+		Title  string
+		Ops    [][4]int
+		Values []int
+	}{title, make([][4]int, len(t)), values}
+	for i, o := range t {
+		r := 0
+		if o.R {
+			r = 1
+		}
+		data.Ops[i] = [4]int{int(o.Kind), o.I, o.J, r}
+	}
+	return htmlTrace.Execute(w, data)
+}
@@ -0,0 +1,99 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import "sort"
+
+// TopK moves the k greatest elements of data to the front, in decreasing
+// order, and returns a Sub view of them. The remaining elements are left in an
+// unspecified order. To select the k least elements, in increasing order, pass
+// NewRev(data). TopK will panic unless 0 <= k <= data.Len().
+func TopK(data sort.Interface, k int) sort.Interface {
+	n := data.Len()
+	if k < 0 || k > n {
+		panic(panicmsg)
+	}
+	rev := NewRev(data)
+	selectK(rev, 0, n, k)
+	sort.Sort(NewSub(rev, 0, k))
+	return NewSub(data, 0, k)
+}
+
+// selectK partitions data[lo:hi] so that its least k-lo elements precede the
+// rest, using quickselect with a three-way partition so that runs of equal
+// elements cannot degrade it.
+func selectK(data sort.Interface, lo, hi, k int) {
+	for hi-lo > 1 {
+		medianOfThree(data, lo, lo+(hi-lo)/2, hi-1)
+		// [lo,lt) < pivot, [lt,i) == pivot, [gt,hi) > pivot
+		lt, i, gt := lo, lo+1, hi
+		for i < gt {
+			if data.Less(i, lt) {
+				data.Swap(i, lt)
+				lt, i = lt+1, i+1
+			} else if data.Less(lt, i) {
+				gt--
+				data.Swap(i, gt)
+			} else {
+				i++
+			}
+		}
+		switch {
+		case k < lt:
+			hi = lt
+		case k > gt:
+			lo = gt
+		default:
+			return
+		}
+	}
+}
+
+// medianOfThree moves the median of the elements at a, b and c to a.
+func medianOfThree(data sort.Interface, a, b, c int) {
+	if data.Less(b, a) {
+		data.Swap(a, b)
+	}
+	if data.Less(c, b) {
+		data.Swap(b, c)
+		if data.Less(b, a) {
+			data.Swap(a, b)
+		}
+	}
+	data.Swap(a, b)
+}
+
+// Rank returns the number of elements of data less than the element at i,
+// which is the index that element would occupy if data were sorted, ahead of
+// any equal elements. Rank makes at most 2(n-1) comparisons and does not modify
+// data.
+func Rank(data sort.Interface, i int) int {
+	r, _ := rank(data, i)
+	return r
+}
+
+// Percentile returns the percentile rank of the element at i: the percentage
+// of elements of data less than it, counting elements equal to it, including
+// itself, as half. Percentile does not modify data.
+func Percentile(data sort.Interface, i int) float64 {
+	r, e := rank(data, i)
+	return 100 * (float64(r) + float64(e)/2) / float64(data.Len())
+}
+
+// rank returns the number of elements of data less than and equal to the
+// element at i.
+func rank(data sort.Interface, i int) (less, equal int) {
+	equal = 1
+	for j, n := 0, data.Len(); j < n; j++ {
+		switch {
+		case j == i:
+		case data.Less(j, i):
+			less++
+		case !data.Less(i, j):
+			equal++
+		}
+	}
+	return less, equal
+}
@@ -6,8 +6,23 @@ package sortutil
 
 import (
 	"bytes"
+	"container/heap"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"net/netip"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewIntSeq(t *testing.T) {
@@ -76,7 +91,7 @@ func TestStat(t *testing.T) {
 	for i := 0; i < Swap; i++ {
 		s.Swap(0, 0)
 	}
-	if s.N.Len != Len || s.N.Less != Less || s.N.Swap != Swap {
+	if s.N.Len != int64(Len) || s.N.Less != int64(Less) || s.N.Swap != int64(Swap) {
 		t.Fail()
 	}
 }
@@ -156,3 +171,2486 @@ func TestSkew(t *testing.T) {
 		try(v.i, v.j)
 	}
 }
+
+func TestLogTime(t *testing.T) {
+	var b bytes.Buffer
+	l := &Log{I: NewLetterSeq(4), W: &b, Time: true}
+	l.Len()
+	l.Swap(0, 1)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	for _, s := range lines {
+		f := strings.Fields(s)
+		if _, err := time.ParseDuration(f[0]); err != nil {
+			t.Error(err)
+		}
+		if _, err := time.ParseDuration(f[1]); err != nil || f[1][0] != '+' {
+			t.Errorf("bad delta in %q", s)
+		}
+	}
+}
+
+func TestLogItemThresh(t *testing.T) {
+	var b bytes.Buffer
+	l := &Log{I: NewLetterSeq(4), W: &b, ItemThresh: 3}
+	l.Len()
+	l.Less(0, 1)
+	if s := b.String(); strings.Contains(s, "abcd") {
+		t.Errorf("data printed above threshold:\n%s", s)
+	}
+	b.Reset()
+	l.ItemThresh = 4
+	l.Len()
+	l.Less(0, 1)
+	if s := b.String(); !strings.Contains(s, "(ABcd).Less") {
+		t.Errorf("data not marked below threshold:\n%s", s)
+	}
+}
+
+func TestColorMarker(t *testing.T) {
+	strip := strings.NewReplacer(colorI, "", colorJ, "", colorReset, "")
+	for _, v := range []sort.Interface{NewLetterSeq(5), NewIntSeq(5), sort.StringSlice{"x", "y", "z"}} {
+		m := ColorMarker{v}.Mark(0, 2)
+		if strip.Replace(m) != fmt.Sprint(v) {
+			t.Errorf("%q does not match %v", m, v)
+		}
+		if !strings.HasPrefix(strings.TrimPrefix(m, "["), colorI) {
+			t.Errorf("%q: index 0 not highlighted", m)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	var b bytes.Buffer
+	s := NewLogStat(&b, NewLetterSeq(4))
+	s.Push("outer")
+	s.Less(0, 1)
+	s.Push("inner")
+	s.Swap(2, 3)
+	s.Pop()
+	s.Pop()
+	s.Swap(0, 1)
+	if c := *s.S["outer"]; c != (Counts{Less: 1}) {
+		t.Errorf("outer: %+v", c)
+	}
+	if c := *s.S["inner"]; c != (Counts{Swap: 1}) {
+		t.Errorf("inner: %+v", c)
+	}
+	want := "outer {\n  Less(0, 1) [true]\n  inner {\n    Swap(2, 3)\n  }\n}\nSwap(0, 1)\n"
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAnalyzeReport(t *testing.T) {
+	var b bytes.Buffer
+	r := Analyze(&b, false, sort.Sort)
+	if r.Failed() || len(r.Results) != len(cases) {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+	if v := r.Results[1]; v.Name != "Ascending" || v.Calls.Less == 0 || v.Trace != "" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+	r = Analyze(&b, false, func(d sort.Interface) { d.Len() })
+	if !r.Failed() || r.Results[1].OK != true || r.Results[0].OK {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+	if tr := r.Results[0].Trace; !strings.HasPrefix(tr, "(vecn") {
+		t.Errorf("unexpected trace: %q", tr)
+	}
+}
+
+func TestRegisterCase(t *testing.T) {
+	defer func(c []Case) { cases = c }(cases)
+	RegisterCase("Equal", func(n int, r *rand.Rand) sort.Interface {
+		return make(sort.IntSlice, n)
+	})
+	r := Analyze(io.Discard, false, sort.Sort)
+	if v := r.Results[len(r.Results)-1]; v.Name != "Equal" || !v.OK {
+		t.Errorf("unexpected result: %+v", v)
+	}
+}
+
+func TestAnalyzeSizes(t *testing.T) {
+	r := Analyze(io.Discard, false, sort.Sort, Sizes(1, 7, 100), Seed(42))
+	if r.Failed() || len(r.Results) != 3*len(cases) {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+	for i, v := range r.Results {
+		if v.Seed != 42 {
+			t.Errorf("%s: seed %d", v.Name, v.Seed)
+		}
+		if v.Name == "Shuffle Prime n=100" && v.Len != 97 {
+			t.Errorf("%s: len %d", v.Name, v.Len)
+		} else if !strings.HasPrefix(v.Name, "Shuffle Prime") && v.Len != []int{1, 7, 100}[i%3] {
+			t.Errorf("%s: len %d", v.Name, v.Len)
+		}
+	}
+	// the first seven datasets are permutations
+	for _, c := range cases[:7] {
+		for _, n := range []int{0, 1, 7, 26, 100} {
+			d := c.Gen(n, rand.New(rand.NewSource(1)))
+			sort.Sort(d)
+			for i := 1; i < d.Len(); i++ {
+				if !d.Less(i-1, i) {
+					t.Errorf("%s n=%d: not a permutation", c.Name, n)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestAnalyzeStable(t *testing.T) {
+	if r := Analyze(io.Discard, false, sort.Stable, Stable()); r.Failed() {
+		t.Errorf("sort.Stable reported unstable: %+v", r)
+	}
+	r := Analyze(io.Discard, false, func(data sort.Interface) {
+		// a stable sort followed by reversing the first run of equal elements
+		sort.Stable(data)
+		i := 1
+		for i < data.Len() && !data.Less(i-1, i) {
+			i++
+		}
+		Reverse(NewSub(data, 0, i))
+	}, Stable())
+	for _, v := range r.Results {
+		if v.Name == "All-Equal" && (v.OK || !strings.HasPrefix(v.Err, "unstable")) {
+			t.Errorf("unexpected result: %+v", v)
+		}
+	}
+}
+
+func TestAnalyzeTiming(t *testing.T) {
+	var b bytes.Buffer
+	r := Analyze(&b, false, sort.Sort, Timing(1, 3))
+	for _, v := range r.Results {
+		if v.NsPerOp <= 0 {
+			t.Errorf("%s: %d ns/op", v.Name, v.NsPerOp)
+		}
+	}
+	if n := strings.Count(b.String(), " ns/op\n"); n != len(cases) {
+		t.Errorf("%d timing lines", n)
+	}
+	// every other run, starting with the first timed one, fails
+	for _, bad := range []func(sort.Interface){
+		func(sort.Interface) { panic("boom") },
+		func(data sort.Interface) {
+			for {
+				data.Less(0, 1)
+			}
+		},
+	} {
+		runs := 0
+		f := func(data sort.Interface) {
+			if runs++; runs%2 == 0 {
+				bad(data)
+			}
+			sort.Sort(data)
+		}
+		r := Analyze(io.Discard, false, f, Timing(1, 2), Sizes(8), Limit(1000, 0))
+		for _, v := range r.Results {
+			if v.OK || !strings.HasPrefix(v.Err, "timing: ") {
+				t.Errorf("%s: got %q", v.Name, v.Err)
+			}
+		}
+	}
+}
+
+var allocSink []int
+
+func TestAnalyzeInPlace(t *testing.T) {
+	if r := Analyze(io.Discard, false, sort.Sort, InPlace()); r.Failed() {
+		t.Errorf("sort.Sort reported allocating: %+v", r)
+	}
+	var b bytes.Buffer
+	r := Analyze(&b, false, func(data sort.Interface) {
+		allocSink = make([]int, data.Len())
+		sort.Sort(data)
+	}, InPlace())
+	for _, v := range r.Results {
+		if v.OK || v.Allocs == 0 || !strings.HasPrefix(v.Err, "made ") {
+			t.Errorf("unexpected result: %+v", v)
+		}
+	}
+	if n := strings.Count(b.String(), "Alloc: "); n != len(cases) {
+		t.Errorf("%d allocation lines", n)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	var b bytes.Buffer
+	r := Compare(&b, map[string]func(sort.Interface){
+		"sort":   sort.Sort,
+		"stable": sort.Stable,
+		"none":   func(sort.Interface) {},
+	})
+	if len(r.Results) != 3*len(cases) || r.Results[0].Alg != "none" {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+	s := b.String()
+	if !strings.Contains(s, "0!") || !strings.Contains(s, "*") || strings.Count(s, "stable") != 3 {
+		t.Errorf("unexpected output:\n%s", s)
+	}
+}
+
+func TestAnalyzePanic(t *testing.T) {
+	r := Analyze(io.Discard, false, func(data sort.Interface) {
+		n := data.Len()
+		if n > 1 && data.Less(0, 1) {
+			data.Swap(0, n)
+		}
+		sort.Sort(data)
+	})
+	for _, v := range r.Results {
+		switch v.Name {
+		case "Ascending":
+			if v.OK || !strings.HasPrefix(v.Err, "panic: ") || v.Trace == "" {
+				t.Errorf("unexpected result: %+v", v)
+			}
+		case "Descending":
+			if !v.OK {
+				t.Errorf("unexpected result: %+v", v)
+			}
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := &Budget{I: NewIntSeq(4), N: 2}
+	b.Less(0, 1)
+	b.Swap(0, 1)
+	defer func() {
+		if v := recover(); v != ErrBudget {
+			t.Errorf("recovered %v", v)
+		}
+	}()
+	b.Less(0, 1)
+}
+
+func TestAnalyzeLimit(t *testing.T) {
+	forever := func(data sort.Interface) {
+		for {
+			data.Less(0, 0)
+		}
+	}
+	for _, opt := range []Option{Limit(1000, 0), Limit(0, time.Millisecond)} {
+		r := Analyze(io.Discard, false, forever, opt)
+		for _, v := range r.Results {
+			if v.OK || !strings.HasPrefix(v.Err, "LIMIT EXCEEDED") {
+				t.Errorf("unexpected result: %+v", v)
+			}
+		}
+	}
+}
+
+func TestAnalyzeT(t *testing.T) {
+	AnalyzeT(t, sort.Stable, Stable(), Sizes(5, 50))
+}
+
+func TestReportEncoding(t *testing.T) {
+	r := Analyze(io.Discard, false, func(d sort.Interface) {
+		d.Swap(0, 1)
+		for i := 0; i < 300; i++ {
+			d.Less(0, 1)
+		}
+	}, Blowup(2))
+	var b bytes.Buffer
+	if err := r.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var r2 Report
+	if err := json.Unmarshal(b.Bytes(), &r2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("JSON round trip: got %+v, want %+v", r2, r)
+	}
+	b.Reset()
+	if err := r.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != len(r.Results)+1 {
+		t.Fatalf("unexpected records: %q", recs)
+	}
+	col := make(map[string]string)
+	for i, h := range recs[0] {
+		col[h] = recs[1][i]
+	}
+	v := r.Results[0]
+	want := map[string]string{
+		"name": "Shuffle", "ok": "false", "id": v.ID, "runs": strconv.Itoa(v.Runs),
+		"ratio": strconv.FormatFloat(v.Ratio, 'g', -1, 64), "warning": v.Warning,
+	}
+	for k, w := range want {
+		if col[k] != w {
+			t.Errorf("column %s: got %q, want %q", k, col[k], w)
+		}
+	}
+	if v.ID == "" || v.Runs == 0 || v.Ratio == 0 || v.Warning == "" {
+		t.Errorf("fields not exercised: %+v", v)
+	}
+}
+
+func TestReportJUnitTAP(t *testing.T) {
+	r := Analyze(io.Discard, false, func(d sort.Interface) {
+		if d.Less(1, 0) {
+			sort.Sort(d)
+		}
+	})
+	var b bytes.Buffer
+	if err := r.WriteJUnit(&b, "sortutil"); err != nil {
+		t.Fatal(err)
+	}
+	var s junitSuite
+	if err := xml.Unmarshal(b.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Tests != len(cases) || s.Failures == 0 || s.Cases[1].Failure != nil {
+		t.Errorf("unexpected suite: %+v", s)
+	}
+	for _, c := range s.Cases {
+		if c.Failure != nil && !strings.HasPrefix(c.Failure.Message, "unsorted at index ") {
+			t.Errorf("unexpected failure: %+v", c.Failure)
+		}
+	}
+	b.Reset()
+	if err := r.WriteTAP(&b); err != nil {
+		t.Fatal(err)
+	}
+	tap := b.String()
+	if !strings.HasPrefix(tap, fmt.Sprintf("TAP version 13\n1..%d\n", len(cases))) ||
+		!strings.Contains(tap, "\nok 2 - Ascending\n") || !strings.Contains(tap, "  ---\n  message: \"unsorted at index ") {
+		t.Errorf("unexpected TAP output:\n%s", tap)
+	}
+}
+
+func TestReportMarkdownHTML(t *testing.T) {
+	r := Compare(io.Discard, map[string]func(sort.Interface){
+		"sort": sort.Sort,
+		"none": func(d sort.Interface) { d.Less(0, 1) },
+	})
+	var b bytes.Buffer
+	if err := r.WriteMarkdown(&b); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	if n := strings.Count(md, "\n| sort | "); n != len(cases) {
+		t.Errorf("%d rows for sort", n)
+	}
+	if !strings.Contains(md, "\n## none/Shuffle\n\nunsorted at index ") || !strings.Contains(md, "█") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+	b.Reset()
+	if err := r.WriteHTML(&b, "a < b"); err != nil {
+		t.Fatal(err)
+	}
+	h := b.String()
+	if !strings.Contains(h, "<title>a &lt; b</title>") || strings.Count(h, "<polyline") != 4*len(cases) ||
+		!strings.Contains(h, "<h2>none/Shuffle</h2>") {
+		t.Errorf("unexpected HTML:\n%s", h)
+	}
+}
+
+func TestTraceHTML(t *testing.T) {
+	data := sort.IntSlice{3, 0, 4, 1, 2}
+	rec := &Recorder{I: data}
+	sort.Sort(rec)
+	var b bytes.Buffer
+	if err := rec.T.WriteHTML(&b, "trace", nil); err != nil {
+		t.Fatal(err)
+	}
+	h := b.String()
+	if !strings.Contains(h, "v = [3,0,4,1,2].slice()") ||
+		!strings.Contains(h, fmt.Sprintf("[%d,%d,%d,%d]", OpLess, rec.T[1].I, rec.T[1].J, boolInt(rec.T[1].R))) {
+		t.Errorf("unexpected HTML:\n%s", h)
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestSpark(t *testing.T) {
+	if s := spark([]int64{0, 1, 2, 3, 4, 5, 6, 7}); s != "▁▂▃▄▅▆▇█" {
+		t.Errorf("got %q", s)
+	}
+	if s := spark(make([]int64, 1000)); len([]rune(s)) != sparkWidth {
+		t.Errorf("got %d runes", len([]rune(s)))
+	}
+}
+
+func TestTraceRoundTrip(t *testing.T) {
+	r := &Recorder{I: NewLetterSeq(5)}
+	Reverse(r)
+	r.Less(0, 1)
+	var b bytes.Buffer
+	r.T.WriteTo(&b)
+	u, err := ReadTrace(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := u.Diff(r.T); d != "" || len(u) != 4 {
+		t.Errorf("round trip: %s", d)
+	}
+	if d := u[:3].Diff(r.T); d != `op 3: got end of trace, want "Less 0 1 false"` {
+		t.Errorf("diff: %s", d)
+	}
+}
+
+func TestAnalyzeGolden(t *testing.T) {
+	dir := t.TempDir()
+	if r := Analyze(io.Discard, false, sort.Sort, Golden(dir, false)); r.Failed() {
+		t.Fatalf("recording failed: %+v", r)
+	}
+	if r := Analyze(io.Discard, false, sort.Sort, Golden(dir, false)); r.Failed() {
+		t.Fatalf("replay failed: %+v", r)
+	}
+	r := Analyze(io.Discard, false, sort.Stable, Golden(dir, false))
+	for _, v := range r.Results {
+		if v.OK || !strings.HasPrefix(v.Err, "trace diverges from golden: op ") {
+			t.Errorf("unexpected result: %+v", v)
+		}
+	}
+	if r := Analyze(io.Discard, false, sort.Stable, Golden(dir, true)); r.Failed() {
+		t.Fatalf("update failed: %+v", r)
+	}
+}
+
+func TestEstimateComplexity(t *testing.T) {
+	shuffle := func(n int, r *rand.Rand) sort.Interface { return sort.IntSlice(r.Perm(n)) }
+	sizes := []int{100, 300, 1000, 3000}
+	insertion := func(data sort.Interface) {
+		for i := 1; i < data.Len(); i++ {
+			for j := i; j > 0 && data.Less(j, j-1); j-- {
+				data.Swap(j, j-1)
+			}
+		}
+	}
+	for _, v := range []struct {
+		f    func(sort.Interface)
+		want string
+	}{
+		{sort.Sort, "n log n"},
+		{insertion, "n^2"},
+		{func(data sort.Interface) { Reverse(data) }, "n"},
+	} {
+		c := EstimateComplexity(v.f, shuffle, sizes)
+		if c.Best() != v.want {
+			t.Errorf("got %s, want %s:\n%v", c.Best(), v.want, c)
+		}
+	}
+}
+
+func TestScaling(t *testing.T) {
+	r := Scaling(sort.Sort, Sizes(100, 300, 1000, 3000))
+	want := map[string]string{"Shuffle": "n log n", "Ascending": "n"}
+	for _, s := range r.Scales {
+		if w, ok := want[s.Name]; ok && s.Ops.Best() != w {
+			t.Errorf("%s: got %s, want %s:\n%v", s.Name, s.Ops.Best(), w, s.Ops)
+		}
+	}
+	var b bytes.Buffer
+	if err := r.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	recs, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(recs), 1+4*len(r.Scales); got != want {
+		t.Errorf("got %d records, want %d", got, want)
+	}
+	if s := r.String(); !strings.Contains(s, "Shuffle") {
+		t.Errorf("missing dataset in report:\n%s", s)
+	}
+}
+
+func TestBentleyMcIlroy(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, v := range []struct {
+		g    Generator
+		want sort.IntSlice
+	}{
+		{Sawtooth(3), sort.IntSlice{0, 1, 2, 0, 1, 2, 0}},
+		{Stagger(2), sort.IntSlice{0, 3, 6, 2, 5, 1, 4}},
+		{Plateau(3), sort.IntSlice{0, 1, 2, 3, 3, 3, 3}},
+		{Reversed(Plateau(3)), sort.IntSlice{3, 3, 3, 3, 2, 1, 0}},
+		{ReversedFront(Sawtooth(3)), sort.IntSlice{2, 1, 0, 0, 1, 2, 0}},
+		{ReversedBack(Sawtooth(3)), sort.IntSlice{0, 1, 2, 0, 2, 1, 0}},
+		{Sorted(Sawtooth(3)), sort.IntSlice{0, 0, 0, 1, 1, 2, 2}},
+		{Dithered(Sawtooth(3)), sort.IntSlice{0, 2, 4, 3, 5, 2, 1}},
+	} {
+		if got := v.g(7, r); !reflect.DeepEqual(got, v.want) {
+			t.Errorf("got %v, want %v", got, v.want)
+		}
+	}
+	s := Shuffled(2)(8, r).(sort.IntSlice)
+	sort.Ints(s)
+	for i := 1; i < len(s); i++ {
+		if s[i] == s[i-1] {
+			t.Errorf("shuffle produced duplicate %d", s[i])
+		}
+	}
+	c := BentleyMcIlroy(10)
+	if len(c) != 5*5*6 || c[0].Name != "sawtooth m=1 copy" {
+		t.Errorf("got %d cases, first %q", len(c), c[0].Name)
+	}
+	for _, v := range c {
+		if d := v.Gen(10, r); d.Len() != 10 {
+			t.Errorf("%s: len %d", v.Name, d.Len())
+		}
+	}
+}
+
+// quicksort is a naive median-of-three quicksort.
+func quicksort(data sort.Interface) {
+	var qs func(lo, hi int)
+	qs = func(lo, hi int) {
+		if hi-lo < 2 {
+			return
+		}
+		m := lo + (hi-lo)/2
+		if data.Less(m, lo) {
+			data.Swap(m, lo)
+		}
+		if data.Less(hi-1, lo) {
+			data.Swap(hi-1, lo)
+		}
+		if data.Less(hi-1, m) {
+			data.Swap(hi-1, m)
+		}
+		data.Swap(m, hi-1)
+		p := lo
+		for i := lo; i < hi-1; i++ {
+			if data.Less(i, hi-1) {
+				data.Swap(i, p)
+				p++
+			}
+		}
+		data.Swap(p, hi-1)
+		qs(lo, p)
+		qs(p+1, hi)
+	}
+	qs(0, data.Len())
+}
+
+func TestAdversary(t *testing.T) {
+	const n = 1000
+	a := &Stat{I: NewAdversary(n)}
+	quicksort(a)
+	if a.N.Less < n*n/8 {
+		t.Errorf("only %d comparisons", a.N.Less)
+	}
+	in := a.I.(*Adversary).Input()
+	s := &Stat{I: in}
+	quicksort(s)
+	if s.N.Less != a.N.Less || !sort.IsSorted(in) || in[0] != 0 || in[n-1] != n-1 {
+		t.Errorf("input made %d comparisons, adversary %d", s.N.Less, a.N.Less)
+	}
+	r := &Stat{I: sort.IntSlice(rand.Perm(n))}
+	quicksort(r)
+	if r.N.Less*10 > a.N.Less {
+		t.Errorf("random input made %d comparisons, adversary %d", r.N.Less, a.N.Less)
+	}
+}
+
+func TestNewNearlySorted(t *testing.T) {
+	for _, d := range []int{0, 1, 5, 100} {
+		s := NewNearlySorted(1000, d, rand.NewSource(int64(d)))
+		max := 0
+		for i, v := range s {
+			if i-v > max {
+				max = i - v
+			} else if v-i > max {
+				max = v - i
+			}
+		}
+		if max > d || d > 0 && max == 0 {
+			t.Errorf("d=%d: max displacement %d", d, max)
+		}
+		sort.Sort(s)
+		if !reflect.DeepEqual(s, NewIntSeq(1000)) {
+			t.Errorf("d=%d: not a permutation", d)
+		}
+	}
+}
+
+func TestNewSwapped(t *testing.T) {
+	s := NewSwapped(100, 1, rand.NewSource(1))
+	k := 0
+	for i, v := range s {
+		if i != v {
+			k++
+		}
+	}
+	if k != 2 {
+		t.Errorf("%d elements out of place", k)
+	}
+	p := Swapped(7)(50, rand.New(rand.NewSource(1))).(sort.IntSlice)
+	if parity := func() int {
+		// the parity of a permutation built from k transpositions is k%2
+		seen, c := make([]bool, len(p)), 0
+		for i := range p {
+			if !seen[i] {
+				c++
+				for j := i; !seen[j]; j = p[j] {
+					seen[j] = true
+				}
+			}
+		}
+		return (len(p) - c) % 2
+	}(); parity != 1 {
+		t.Errorf("parity %d after 7 transpositions", parity)
+	}
+}
+
+func TestFewUniqueSkewed(t *testing.T) {
+	count := func(s sort.IntSlice) map[int]int {
+		m := make(map[int]int)
+		for _, v := range s {
+			m[v]++
+		}
+		return m
+	}
+	if m := count(NewFewUnique(1000, 3, rand.NewSource(1))); len(m) != 3 || m[0] < 250 || m[3] != 0 {
+		t.Errorf("few unique: %v", m)
+	}
+	m := count(NewSkewed(1000, 5, 0.9, rand.NewSource(1)))
+	if len(m) != 5 || m[0] < 850 || m[0] > 950 {
+		t.Errorf("skewed: %v", m)
+	}
+}
+
+func TestDistributions(t *testing.T) {
+	mean := func(s []float64) float64 {
+		m := 0.0
+		for _, v := range s {
+			m += v
+		}
+		return m / float64(len(s))
+	}
+	if m := mean(NewGaussian(10000, 5, 2, rand.NewSource(1))); m < 4.9 || m > 5.1 {
+		t.Errorf("gaussian mean %v", m)
+	}
+	if m := mean(NewExponential(10000, 4, rand.NewSource(1))); m < 0.24 || m > 0.26 {
+		t.Errorf("exponential mean %v", m)
+	}
+	z := NewZipf(10000, 2, 1, 100, rand.NewSource(1))
+	zeros := 0
+	for _, v := range z {
+		if v < 0 || v > 100 {
+			t.Fatalf("zipf value %d out of range", v)
+		}
+		if v == 0 {
+			zeros++
+		}
+	}
+	if zeros < len(z)/2 {
+		t.Errorf("zipf produced only %d zeros", zeros)
+	}
+}
+
+func TestDataset(t *testing.T) {
+	r := Analyze(io.Discard, false, func(d sort.Interface) {}, Sizes(30), Seed(7))
+	v := r.Results[0]
+	if v.ID != "Shuffle:30:7" {
+		t.Fatalf("ID %q", v.ID)
+	}
+	d, err := ParseDataset(v.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cases[0].Gen(30, rand.New(rand.NewSource(7)))
+	if got := d.Generate(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	d, err = ParseDataset("sawtooth m=4 reverse:10:1")
+	if err != nil || fmt.Sprint(d.Generate()) != "[1 0 3 2 1 0 3 2 1 0]" {
+		t.Errorf("got %v, %v", d.Generate(), err)
+	}
+	RegisterGenerator("gauss:ian", Gaussian(0, 1))
+	defer delete(generators, "gauss:ian")
+	if d, err := ParseDataset("gauss:ian:5:1"); err != nil || d.Generate().Len() != 5 {
+		t.Errorf("got %v, %v", d, err)
+	}
+	for _, id := range []string{"nope:1:1", "Shuffle:x:1", "Shuffle"} {
+		if _, err := ParseDataset(id); err == nil {
+			t.Errorf("%q: no error", id)
+		}
+	}
+}
+
+func TestVerifyAll(t *testing.T) {
+	seen := make(map[string]bool)
+	count := func(data sort.Interface) {
+		seen[fmt.Sprint(data)] = true
+		sort.Sort(data)
+	}
+	if err := VerifyAll(count, 5); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1+1+2+6+24+120 {
+		t.Errorf("saw %d permutations", len(seen))
+	}
+	// a bubble sort that skips the last pass
+	buggy := func(data sort.Interface) {
+		n := data.Len()
+		for i := 0; i < n-2; i++ {
+			for j := 0; j < n-1-i; j++ {
+				if data.Less(j+1, j) {
+					data.Swap(j, j+1)
+				}
+			}
+		}
+	}
+	err := VerifyAll(buggy, 6)
+	v, ok := err.(*VerifyError)
+	if !ok || len(v.Input) != 2 || v.Reason != "unsorted at index 1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyMultisets(t *testing.T) {
+	n := 0
+	count := func(data sort.Interface) {
+		n++
+		sort.Stable(data)
+	}
+	if err := VerifyMultisets(count, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1+3+9+27+81 {
+		t.Errorf("ran %d sequences", n)
+	}
+	// an insertion sort that gives up upon finding equal neighbors
+	buggy := func(data sort.Interface) {
+		for i := 1; i < data.Len(); i++ {
+			if !data.Less(i-1, i) && !data.Less(i, i-1) {
+				return
+			}
+			for j := i; j > 0 && data.Less(j, j-1); j-- {
+				data.Swap(j, j-1)
+			}
+		}
+	}
+	if err := VerifyAll(buggy, 6); err != nil {
+		t.Fatalf("distinct values should sort: %v", err)
+	}
+	if err := VerifyMultisets(buggy, 3, 6); err == nil {
+		t.Error("no error")
+	}
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("no panic for zero symbols")
+		}
+	}()
+	VerifyMultisets(sort.Sort, 0, 1)
+}
+
+func TestBounds(t *testing.T) {
+	b := &Bounds{I: NewIntSeq(3)}
+	b.Swap(0, 2)
+	defer func() {
+		if v := recover(); v != "sortutil: Less(1, 3) out of range [0,3)" {
+			t.Errorf("recovered %v", v)
+		}
+	}()
+	b.Less(1, 3)
+}
+
+func FuzzSort(f *testing.F) { Fuzz(f, sort.Sort) }
+
+func TestCheck(t *testing.T) {
+	if err := Check(sort.Sort); err != nil {
+		t.Fatal(err)
+	}
+	// an insertion sort that ignores the last element of inputs longer than 4
+	buggy := func(data sort.Interface) {
+		n := data.Len()
+		if n > 4 {
+			n--
+		}
+		for i := 1; i < n; i++ {
+			for j := i; j > 0 && data.Less(j, j-1); j-- {
+				data.Swap(j, j-1)
+			}
+		}
+	}
+	err := Check(buggy)
+	v, ok := err.(*VerifyError)
+	if !ok || len(v.Input) != 5 || v.Input[4] != 0 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the minimal input is four zeros and a one, with the one not last
+	sum := 0
+	for _, x := range v.Input {
+		sum += x
+	}
+	if sum != 1 {
+		t.Errorf("input not minimal: %v", v.Input)
+	}
+	// a sort that loses elements by type-asserting its input
+	lossy := func(data sort.Interface) {
+		s := data.(sort.IntSlice)
+		if len(s) > 2 {
+			s[0] = s[1]
+		}
+		sort.Sort(s)
+	}
+	err = Check(lossy)
+	if v, ok := err.(*VerifyError); !ok || len(v.Input) != 3 || !strings.Contains(v.Reason, "permutation") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// lossy on every other run, so that the output checked for being a
+	// permutation must be the one checked for being sorted
+	runs := 0
+	flaky := func(data sort.Interface) {
+		if runs++; runs%2 == 1 {
+			lossy(data)
+		} else {
+			sort.Sort(data)
+		}
+	}
+	err = Check(flaky)
+	if v, ok := err.(*VerifyError); !ok || !strings.Contains(v.Reason, "permutation") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckStable(t *testing.T) {
+	if err := CheckStable(sort.Stable); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckStable(sort.Sort)
+	v, ok := err.(*StabilityError)
+	if !ok || v.Input[v.Orig[0]] != v.Key || v.Input[v.Orig[1]] != v.Key || v.Orig[0] <= v.Orig[1] {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, ok := CheckStable(func(sort.Interface) {}).(*VerifyError); !ok {
+		t.Error("unsorted output not reported")
+	}
+}
+
+func TestDiffAgainstStdlib(t *testing.T) {
+	if err := DiffAgainstStdlib(sort.Sort, RandMod(10), 100); err != nil {
+		t.Fatal(err)
+	}
+	err := DiffAgainstStdlib(quicksort, Swapped(3), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = DiffAgainstStdlib(func(data sort.Interface) {
+		sort.Sort(data)
+		if n := data.Len(); n > 10 {
+			data.Swap(n-1, n-2)
+		}
+	}, func(n int, r *rand.Rand) sort.Interface { return sort.IntSlice(r.Perm(n)) }, 100)
+	if v, ok := err.(*DiffError); !ok || v.Len <= 10 || v.Index != v.Len-2 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestShuffleR(t *testing.T) {
+	a, b := NewLetterSeq(26), NewLetterSeq(26)
+	ShuffleR(a, rand.New(rand.NewSource(3)))
+	ShuffleR(b, rand.New(rand.NewSource(3)))
+	if a.String() != b.String() || sort.IsSorted(a) {
+		t.Errorf("%s != %s", a, b)
+	}
+	c, d := NewLetterSeq(26), NewLetterSeq(26)
+	ShuffleV2(c, randv2.New(randv2.NewPCG(1, 2)))
+	ShuffleV2(d, randv2.New(randv2.NewPCG(1, 2)))
+	if c.String() != d.String() || sort.IsSorted(c) {
+		t.Errorf("%s != %s", c, d)
+	}
+}
+
+func TestShuffleUniform(t *testing.T) {
+	const trials = 60000
+	r := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	for i := 0; i < trials; i++ {
+		s := NewLetterSeq(3)
+		ShuffleR(s, r)
+		counts[s.String()]++
+	}
+	if len(counts) != 6 {
+		t.Fatalf("saw %d permutations", len(counts))
+	}
+	for k, v := range counts {
+		if v < trials/6*9/10 || v > trials/6*11/10 {
+			t.Errorf("%s occurred %d times", k, v)
+		}
+	}
+}
+
+func TestShuffleCrypto(t *testing.T) {
+	b := NewLetterSeq(26)
+	ShuffleCrypto(b)
+	if sort.IsSorted(b) {
+		t.Error("not shuffled")
+	}
+	sort.Sort(b)
+	if b.String() != NewLetterSeq(26).String() {
+		t.Errorf("not a permutation: %s", b)
+	}
+}
+
+func TestShuffleN(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	counts := make([]int, 10)
+	for i := 0; i < 10000; i++ {
+		s := NewIntSeq(10)
+		ShuffleN(s, 3, r)
+		for _, v := range s[:3] {
+			counts[v]++
+		}
+		sort.Sort(s)
+		if !reflect.DeepEqual(s, NewIntSeq(10)) {
+			t.Fatalf("not a permutation: %v", s)
+		}
+	}
+	for v, c := range counts {
+		if c < 2700 || c > 3300 {
+			t.Errorf("%d sampled %d times", v, c)
+		}
+	}
+}
+
+func TestRangeOps(t *testing.T) {
+	s := NewLetterSeq(10)
+	ReverseRange(s, 2, 7)
+	if s.String() != "abgfedchij" {
+		t.Errorf("got %s", s)
+	}
+	ReverseRange(s, 4, 4)
+	ReverseRange(s, 9, 10)
+	if s.String() != "abgfedchij" {
+		t.Errorf("got %s", s)
+	}
+	s = NewLetterSeq(26)
+	ShuffleRange(s, 5, 20, rand.New(rand.NewSource(1)))
+	if s[:5].String() != "abcde" || s[20:].String() != "uvwxyz" || sort.IsSorted(s[5:20]) {
+		t.Errorf("got %s", s)
+	}
+	sort.Sort(s)
+	if s.String() != NewLetterSeq(26).String() {
+		t.Errorf("not a permutation: %s", s)
+	}
+}
+
+func TestDerange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	for i := 0; i < 9000; i++ {
+		s := NewLetterSeq(4)
+		Derange(s, r)
+		for j, c := range s {
+			if c == 'a'+byte(j) {
+				t.Fatalf("%s: fixed point at %d", s, j)
+			}
+		}
+		counts[s.String()]++
+	}
+	// there are 9 derangements of 4 elements
+	if len(counts) != 9 {
+		t.Fatalf("saw %d derangements", len(counts))
+	}
+	for k, v := range counts {
+		if v < 900 || v > 1100 {
+			t.Errorf("%s occurred %d times", k, v)
+		}
+	}
+	Derange(Letters{}, r)
+}
+
+func TestSampleToFront(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	counts := make([]int, 10)
+	first := make([]int, 10)
+	for i := 0; i < 10000; i++ {
+		s := NewIntSeq(10)
+		SampleToFront(s, 4, r)
+		for _, v := range s[:4] {
+			counts[v]++
+		}
+		first[s[0]]++
+		sort.Sort(s)
+		if !reflect.DeepEqual(s, NewIntSeq(10)) {
+			t.Fatalf("not a permutation: %v", s)
+		}
+	}
+	for v := range counts {
+		if counts[v] < 3700 || counts[v] > 4300 || first[v] < 850 || first[v] > 1150 {
+			t.Errorf("%d sampled %d times, first %d times", v, counts[v], first[v])
+		}
+	}
+}
+
+func TestNextPermutation(t *testing.T) {
+	s := Letters("aabc")
+	var seen []string
+	for ok := true; ok; ok = NextPermutation(s) {
+		seen = append(seen, s.String())
+	}
+	// 4!/2! distinct permutations, in lexicographic order
+	if len(seen) != 12 || !sort.StringsAreSorted(seen) || s.String() != "aabc" {
+		t.Errorf("got %v, ending with %s", seen, s)
+	}
+	for i := len(seen) - 1; i > 0; i-- {
+		copy(s, seen[i])
+		if !PrevPermutation(s) || s.String() != seen[i-1] {
+			t.Errorf("prev of %s: got %s, want %s", seen[i], s, seen[i-1])
+		}
+	}
+	if PrevPermutation(s) || s.String() != "cbaa" {
+		t.Errorf("prev of least permutation: got %s", s)
+	}
+}
+
+func TestPermRank(t *testing.T) {
+	p := NewPermutation(4)
+	for i := int64(0); ; i++ {
+		if r := PermRank(p); r.Int64() != i {
+			t.Errorf("rank of %v: got %v, want %d", p, r, i)
+		}
+		if q := PermUnrank(4, big.NewInt(i)); !reflect.DeepEqual(p, q) {
+			t.Errorf("unrank of %d: got %v, want %v", i, q, p)
+		}
+		if !NextPermutation(p) {
+			break
+		}
+	}
+	// round trip a large permutation
+	long := Permutation(rand.New(rand.NewSource(1)).Perm(100))
+	if q := PermUnrank(100, PermRank(long)); !reflect.DeepEqual(long, q) {
+		t.Errorf("round trip failed: %v", q)
+	}
+}
+
+func TestParity(t *testing.T) {
+	for _, v := range []struct {
+		p    Permutation
+		want int
+	}{
+		{Permutation{}, 0},
+		{Permutation{0, 1, 2}, 0},
+		{Permutation{1, 0, 2}, 1},
+		{Permutation{1, 2, 0}, 0},
+		{Permutation{3, 2, 1, 0}, 0},
+		{Permutation{1, 2, 3, 0}, 1},
+	} {
+		if got := Parity(v.p); got != v.want {
+			t.Errorf("%v: got %d, want %d", v.p, got, v.want)
+		}
+	}
+	data := sort.IntSlice(rand.New(rand.NewSource(1)).Perm(101))
+	p := NewPermutation(len(data))
+	s := &Stat{I: NewProxy(data, p)}
+	sort.Sort(s)
+	if Parity(p) != s.Parity() {
+		t.Errorf("permutation parity %d, swap parity %d", Parity(p), s.Parity())
+	}
+}
+
+func TestRotateMin(t *testing.T) {
+	for n := 0; n < 30; n++ {
+		for d := -n; d <= n; d++ {
+			a, b := NewLetterSeq(n), NewLetterSeq(n)
+			if n > 0 {
+				// sub hides MoveInterface, forcing the Skew-based path
+				Rotate(NewSub(a, 0, n), d)
+			}
+			RotateMin(b, d)
+			if a.String() != b.String() {
+				t.Errorf("n=%d d=%d: got %s, want %s", n, d, b, a)
+			}
+		}
+	}
+}
+
+func benchmarkRotate(b *testing.B, n int, f func(Letters, int)) {
+	s := NewLetterSeq(n)
+	for i := 0; i < b.N; i++ {
+		f(s, n/3+1)
+	}
+}
+
+func skewRotate(s Letters, d int) { Skew(s, 0, d, len(s)-d) }
+
+func BenchmarkRotateSkew100(b *testing.B) { benchmarkRotate(b, 100, skewRotate) }
+func BenchmarkRotateSkew10k(b *testing.B) { benchmarkRotate(b, 10000, skewRotate) }
+func BenchmarkRotateSkew1M(b *testing.B)  { benchmarkRotate(b, 1000000, skewRotate) }
+func BenchmarkRotateMin100(b *testing.B) {
+	benchmarkRotate(b, 100, func(s Letters, d int) { RotateMin(s, d) })
+}
+func BenchmarkRotateMin10k(b *testing.B) {
+	benchmarkRotate(b, 10000, func(s Letters, d int) { RotateMin(s, d) })
+}
+func BenchmarkRotateMin1M(b *testing.B) {
+	benchmarkRotate(b, 1000000, func(s Letters, d int) { RotateMin(s, d) })
+}
+
+func TestSwapRange(t *testing.T) {
+	s := NewLetterSeq(10)
+	SwapRange(s, 6, 1, 3)
+	if want := "aghiefbcdj"; s.String() != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	SwapRange(s, 2, 2, 0)
+	for _, c := range [][3]int{{0, 2, 3}, {8, 0, 3}, {-1, 4, 2}, {0, 5, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("SwapRange(%v) did not panic", c)
+				}
+			}()
+			SwapRange(s, c[0], c[1], c[2])
+		}()
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	tests := []struct {
+		n    int
+		in   bool
+		want string
+	}{
+		{8, false, "aebfcgdh"},
+		{8, true, "eafbgchd"},
+		{7, false, "aebfcgd"},
+		{7, true, "daebfcg"},
+		{0, false, ""},
+	}
+	for _, test := range tests {
+		s := NewLetterSeq(test.n)
+		if test.in {
+			InterleaveIn(s)
+		} else {
+			Interleave(s)
+		}
+		if s.String() != test.want {
+			t.Errorf("n=%d in=%v: got %s, want %s", test.n, test.in, s, test.want)
+		}
+	}
+	// compare against the definition at lengths spanning several passes
+	for n := 0; n < 100; n++ {
+		for _, in := range []bool{false, true} {
+			s := NewIntSeq(n)
+			h, a, b := (n+1)/2, 0, 1
+			if in {
+				h, a, b = n/2, 1, 0
+				InterleaveIn(s)
+			} else {
+				Interleave(s)
+			}
+			for i := 0; i < n; i++ {
+				want := 2*(i-h) + b
+				if i < h {
+					want = 2*i + a
+				}
+				if s[want] != i {
+					t.Fatalf("n=%d in=%v: got %v", n, in, s)
+				}
+			}
+		}
+	}
+}
+
+func TestRiffle(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		s := NewLetterSeq(26)
+		Riffle(s, r)
+		// a single riffle yields at most two rising sequences
+		rising := 1
+		for j := 1; j < len(s); j++ {
+			if bytes.IndexByte(s, 'a'+byte(j)) < bytes.IndexByte(s, 'a'+byte(j-1)) {
+				rising++
+			}
+		}
+		sort.Sort(s)
+		if s.String() != NewLetterSeq(26).String() || rising > 2 {
+			t.Fatalf("riffle %d: %d rising sequences", i, rising)
+		}
+	}
+}
+
+func TestCutDeal(t *testing.T) {
+	s := NewLetterSeq(7)
+	Cut(s, 3)
+	if want := "defgabc"; s.String() != want {
+		t.Errorf("Cut: got %s, want %s", s, want)
+	}
+	Cut(s, 0)
+	Cut(s, 7)
+	if want := "defgabc"; s.String() != want {
+		t.Errorf("Cut: got %s, want %s", s, want)
+	}
+	tests := []struct {
+		n, piles int
+		want     string
+	}{
+		{7, 3, "gdaebfc"},
+		{6, 2, "ecafdb"},
+		{4, 1, "dcba"},
+		{2, 5, "ab"},
+	}
+	for _, test := range tests {
+		s := NewLetterSeq(test.n)
+		Deal(s, test.piles)
+		if s.String() != test.want {
+			t.Errorf("Deal(%d, %d): got %s, want %s", test.n, test.piles, s, test.want)
+		}
+	}
+}
+
+func TestStride(t *testing.T) {
+	s := NewLetterSeq(11)
+	Reverse(s)
+	v := NewStride(s, 1, 3)
+	if v.Len() != 4 {
+		t.Fatalf("Len: got %d, want 4", v.Len())
+	}
+	sort.Sort(v)
+	if want := "kaihdfegcbj"; s.String() != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	v = NewStride(NewStride(s, 0, 2), 1, 2)
+	if v.Len() != 3 {
+		t.Fatalf("Len: got %d, want 3", v.Len())
+	}
+	v.Swap(0, 2)
+	if want := "kajhdfegcbi"; s.String() != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	if n := NewStride(s, 11, 4).Len(); n != 0 {
+		t.Errorf("Len: got %d, want 0", n)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	a, b, c := Letters("qzc"), Letters(""), Letters("mab")
+	s := NewConcat(a, b, c)
+	if s.Len() != 6 {
+		t.Fatalf("Len: got %d, want 6", s.Len())
+	}
+	sort.Sort(s)
+	if got := a.String() + "|" + c.String(); got != "abc|mqz" {
+		t.Errorf("got %s, want abc|mqz", got)
+	}
+	for _, v := range []sort.Interface{
+		ByteSlice{}, Letters{}, Float64Slice{}, FoldedStringSlice{}, TimeSlice{}, DurationSlice{},
+		AddrSlice{}, PrefixSlice{}, BigIntSlice{}, BigFloatSlice{}, RatSlice{}, Symbols{}, Bars{},
+		NaturalStringSlice{}, VersionSlice{}, Permutation{}, Int8Slice{}, Int16Slice{}, Int32Slice{},
+		Int64Slice{}, Uint16Slice{}, Uint32Slice{}, Uint64Slice{}, UintptrSlice{},
+	} {
+		if _, ok := v.(ValueLesser); !ok {
+			t.Errorf("%T does not implement ValueLesser", v)
+		}
+	}
+	f, g := FoldedStringSlice{"b", "D"}, FoldedStringSlice{"c", "A"}
+	sort.Sort(NewConcat(f, g))
+	if got := fmt.Sprint(f, g); got != "[A b] [c D]" {
+		t.Errorf("got %s", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("cross-part Swap of non-movable parts did not panic")
+		}
+	}()
+	NewConcat(sort.IntSlice{1}, sort.IntSlice{0}).Swap(0, 1)
+}
+
+func TestSelect(t *testing.T) {
+	s := Letters("zyxwvut")
+	sort.Sort(NewSelect(s, []int{5, 1, 3}))
+	if want := "zwxyvut"; s.String() != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	for _, idx := range [][]int{{0, 0}, {7}, {-1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewSelect(%v) did not panic", idx)
+				}
+			}()
+			NewSelect(s, idx)
+		}()
+	}
+}
+
+func TestMultiKey(t *testing.T) {
+	a := sort.IntSlice{2, 1, 2, 1, 2}
+	b := sort.StringSlice{"x", "y", "w", "x", "x"}
+	c := sort.Float64Slice{5, 4, 3, 2, 1}
+	sort.Sort(NewMultiKey(a, b, c))
+	got := fmt.Sprint(a, b, c)
+	if want := "[1 1 2 2 2] [x y w x x] [2 4 3 1 5]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	type rec struct {
+		name string
+		age  int
+	}
+	bob, amy, cal, ann := &rec{"bob", 30}, &rec{"amy", 30}, &rec{"cal", 40}, &rec{"amy", 20}
+	items := []*rec{bob, nil, amy, cal, ann}
+	age := Key(func(r *rec) int { return r.age })
+	name := Key(func(r *rec) string { return r.name })
+	OrderBy(items).Desc(age).Asc(name).Sort()
+	want := []*rec{cal, amy, bob, ann, nil}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+	OrderBy(items).NilsFirst().Asc(name).Stable()
+	want = []*rec{nil, amy, ann, bob, cal}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+	// only types with nil values are checked for nil
+	if OrderBy([]rec{}).nilable || OrderBy([]int{}).nilable || !OrderBy(items).nilable ||
+		!OrderBy([]interface{}{}).nilable || !OrderBy([][]int{}).nilable {
+		t.Error("wrong nilable")
+	}
+	vals := []interface{}{2, nil, 1}
+	OrderBy(vals).Asc(func(a, b interface{}) int { return a.(int) - b.(int) }).Sort()
+	if fmt.Sprint(vals) != "[1 2 <nil>]" {
+		t.Errorf("got %v", vals)
+	}
+}
+
+func TestAny(t *testing.T) {
+	s := []struct{ k int }{{3}, {1}, {2}}
+	st := NewStat(Any(s, func(i, j int) bool { return s[i].k < s[j].k }))
+	sort.Sort(st)
+	if fmt.Sprint(s) != "[{1} {2} {3}]" || st.N.Less == 0 {
+		t.Errorf("got %v with %+v", s, st.N)
+	}
+}
+
+func TestSliceFastPaths(t *testing.T) {
+	for n := 0; n < 12; n++ {
+		a, b := NewLetterSeq(n), NewLetterSeq(n)
+		Reverse(NewSub(a, 0, n))
+		ReverseSlice(b)
+		if a.String() != b.String() {
+			t.Errorf("ReverseSlice(%d): got %s, want %s", n, b, a)
+		}
+		for d := -n; d <= n; d++ {
+			a, b := NewLetterSeq(n), NewLetterSeq(n)
+			if n > 0 {
+				Rotate(NewSub(a, 0, n), d)
+			}
+			RotateSlice(b, d)
+			if a.String() != b.String() {
+				t.Errorf("RotateSlice(%d, %d): got %s, want %s", n, d, b, a)
+			}
+		}
+		for k := 0; k <= n; k++ {
+			for i := 0; i+k <= n; i++ {
+				for j := 0; j+k <= n; j++ {
+					a, b := NewLetterSeq(n), NewLetterSeq(n)
+					Skew(a, i, j, k)
+					SkewSlice(b, i, j, k)
+					if a.String() != b.String() {
+						t.Errorf("SkewSlice(%d, %d, %d, %d): got %s, want %s", n, i, j, k, b, a)
+					}
+				}
+			}
+		}
+		a, b = NewLetterSeq(n), NewLetterSeq(n)
+		ShuffleR(a, rand.New(rand.NewSource(int64(n))))
+		ShuffleSlice(b, rand.New(rand.NewSource(int64(n))))
+		if a.String() != b.String() {
+			t.Errorf("ShuffleSlice(%d): got %s, want %s", n, b, a)
+		}
+	}
+}
+
+func BenchmarkReverse(b *testing.B) {
+	s := NewIntSeq(10000)
+	for i := 0; i < b.N; i++ {
+		Reverse(s)
+	}
+}
+
+func BenchmarkReverseSlice(b *testing.B) {
+	s := NewIntSeq(10000)
+	for i := 0; i < b.N; i++ {
+		ReverseSlice(s)
+	}
+}
+
+func TestFloat64Slice(t *testing.T) {
+	nan := math.NaN()
+	s := []float64{3, nan, 1, nan, 2}
+	sort.Sort(Float64Slice{S: s})
+	if got := fmt.Sprint(s); got != "[NaN NaN 1 2 3]" {
+		t.Errorf("NaNFirst: got %s", got)
+	}
+	sort.Sort(Float64Slice{s, NaNLast})
+	if got := fmt.Sprint(s); got != "[1 2 3 NaN NaN]" {
+		t.Errorf("NaNLast: got %s", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NaNPanic did not panic")
+		}
+	}()
+	sort.Sort(Float64Slice{s, NaNPanic})
+}
+
+func TestFoldedStringSlice(t *testing.T) {
+	s := FoldedStringSlice{"banana", "Apple", "apricot", "ÉCLAIR", "apple", "éclair", "app", "Zed"}
+	sort.Stable(s)
+	want := "[app Apple apple apricot banana Zed ÉCLAIR éclair]"
+	if got := fmt.Sprint(s); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestNaturalStringSlice(t *testing.T) {
+	s := NaturalStringSlice{"file10", "file2", "file02", "file", "file1a", "file1", "a100b2", "a100b10"}
+	sort.Sort(s)
+	want := "[a100b2 a100b10 file file1 file1a file02 file2 file10]"
+	if got := fmt.Sprint(s); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestVersionSlice(t *testing.T) {
+	s := VersionSlice{"v1.10.0", "1.2", "v1.2.0-rc.1", "1.2.0-beta.11", "1.2.0-beta.2", "1.2.0-beta", "1.2.0-alpha", "v0.9+build.5", "1.2.1"}
+	sort.Sort(s)
+	want := "[v0.9+build.5 1.2.0-alpha 1.2.0-beta 1.2.0-beta.2 1.2.0-beta.11 v1.2.0-rc.1 1.2 1.2.1 v1.10.0]"
+	if got := fmt.Sprint(s); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestTimeSlice(t *testing.T) {
+	t0 := time.Date(2013, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := TimeSlice{t0.Add(time.Hour), t0, t0.Add(-time.Second)}
+	sort.Sort(s)
+	want := "[2013-01-02T03:04:04Z 2013-01-02T03:04:05Z <2013-01-02T04:04:05Z>]"
+	if got := s.Mark(2, 2); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	d := DurationSlice{time.Second, time.Millisecond, time.Minute}
+	var buf bytes.Buffer
+	sort.Sort(&Log{I: d, W: &buf})
+	if d.String() != "[1ms 1s 1m0s]" {
+		t.Errorf("got %s", d)
+	}
+	if !strings.Contains(buf.String(), "<1s> <1ms>") {
+		t.Errorf("log lacks marks:\n%s", buf.String())
+	}
+}
+
+func TestIntSlices(t *testing.T) {
+	tests := []sort.Interface{
+		Int8Slice{3, -128, 127, 0},
+		Int16Slice{3, -1, 2},
+		Int32Slice{3, -1, 2},
+		Int64Slice{3, -1 << 62, 2},
+		Uint16Slice{3, 65535, 2},
+		Uint32Slice{3, 1, 2},
+		Uint64Slice{3, 1 << 63, 2},
+		UintptrSlice{3, 1, 2},
+	}
+	for _, s := range tests {
+		RotateMin(s.(MoveInterface), 1)
+		sort.Sort(s)
+		if !sort.IsSorted(s) {
+			t.Errorf("%T not sorted: %v", s, s)
+		}
+	}
+}
+
+func TestAddrSlice(t *testing.T) {
+	var a AddrSlice
+	for _, s := range []string{"::1", "10.0.0.2", "fe80::1%eth0", "9.255.0.1", "10.0.0.10", "fe80::1"} {
+		a = append(a, netip.MustParseAddr(s))
+	}
+	sort.Sort(a)
+	if want := "[9.255.0.1 10.0.0.2 10.0.0.10 ::1 fe80::1 fe80::1%eth0]"; a.String() != want {
+		t.Errorf("got %s, want %s", a, want)
+	}
+	var p PrefixSlice
+	for _, s := range []string{"10.0.0.0/16", "10.0.0.0/8", "::/0", "9.0.0.0/8"} {
+		p = append(p, netip.MustParsePrefix(s))
+	}
+	sort.Sort(p)
+	if want := "[9.0.0.0/8 10.0.0.0/8 10.0.0.0/16 ::/0]"; p.String() != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+}
+
+func TestBigSlices(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000000000000", 10)
+	i := BigIntSlice{huge, big.NewInt(-1), big.NewInt(7)}
+	sort.Sort(i)
+	if got := fmt.Sprint(i); got != "[-1 7 100000000000000000000000000000]" {
+		t.Errorf("BigIntSlice: got %s", got)
+	}
+	f := BigFloatSlice{big.NewFloat(2.5), big.NewFloat(-3), new(big.Float).SetInf(false)}
+	sort.Sort(f)
+	if got := fmt.Sprint(f); got != "[-3 2.5 +Inf]" {
+		t.Errorf("BigFloatSlice: got %s", got)
+	}
+	r := RatSlice{big.NewRat(1, 3), big.NewRat(1, 4), big.NewRat(-2, 3)}
+	sort.Sort(r)
+	if got := fmt.Sprint(r); got != "[-2/3 1/4 1/3]" {
+		t.Errorf("RatSlice: got %s", got)
+	}
+}
+
+func TestSortedMap(t *testing.T) {
+	m := map[string]int{"b": 1, "c": 3, "a": 2}
+	keys := SortedKeys(m, func(a, b string) bool { return a > b })
+	if got := fmt.Sprint(keys); got != "[c b a]" {
+		t.Errorf("SortedKeys: got %s", got)
+	}
+	kvs := SortedByValue(m, func(a, b int) bool { return a < b })
+	if got := fmt.Sprint(kvs); got != "[{b 1} {a 2} {c 3}]" {
+		t.Errorf("SortedByValue: got %s", got)
+	}
+}
+
+func TestNulls(t *testing.T) {
+	one, two, three := 1, 2, 3
+	s := []*int{&three, nil, &one, nil, &two}
+	less := func(i, j int) bool { return *s[i] < *s[j] }
+	isNull := func(i int) bool { return s[i] == nil }
+	str := func() string {
+		var b bytes.Buffer
+		for _, p := range s {
+			if p == nil {
+				b.WriteString("-")
+			} else {
+				fmt.Fprint(&b, *p)
+			}
+		}
+		return b.String()
+	}
+	sort.Sort(NullsLast(Any(s, less), isNull))
+	if got := str(); got != "123--" {
+		t.Errorf("NullsLast: got %s", got)
+	}
+	sort.Sort(NullsFirst(Any(s, less), isNull))
+	if got := str(); got != "--123" {
+		t.Errorf("NullsFirst: got %s", got)
+	}
+}
+
+func TestSortGroups(t *testing.T) {
+	day := []int{1, 1, 1, 2, 3, 3, 1, 1}
+	amt := sort.IntSlice{5, 3, 4, 9, 2, 1, 8, 7}
+	data := NewProxy(amt, sort.IntSlice(day))
+	calls := 0
+	SortGroups(data, func(i, j int) bool { return day[i] == day[j] }, func(s sort.Interface) {
+		calls++
+		sort.Sort(s)
+	})
+	if got := fmt.Sprint(amt); got != "[3 4 5 9 1 2 7 8]" || calls != 3 {
+		t.Errorf("got %s after %d calls", got, calls)
+	}
+}
+
+func TestChunked(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, buffered := range []bool{false, true} {
+		for _, n := range []int{0, 1, 7, 100, 1000} {
+			s := make(Int64Slice, n)
+			for i := range s {
+				s[i] = int64(r.Intn(50))
+			}
+			want := append(Int64Slice(nil), s...)
+			sort.Stable(want)
+			c := &Chunked{Size: 16, Workers: 3, Buffered: buffered}
+			c.Sort(s, sort.Stable)
+			if fmt.Sprint(s) != fmt.Sprint(want) {
+				t.Errorf("n=%d buffered=%v: got %v", n, buffered, s)
+			}
+			if len(c.Chunks) != (n+15)/16 || n > 16 && c.Merge.Less == 0 {
+				t.Errorf("n=%d buffered=%v: %d chunks, merge %+v", n, buffered, len(c.Chunks), c.Merge)
+			}
+		}
+	}
+	defer func() {
+		if e := recover(); e != "boom" {
+			t.Errorf("got panic %v, want boom", e)
+		}
+	}()
+	new(Chunked).Sort(NewIntSeq(10), func(sort.Interface) { panic("boom") })
+}
+
+func TestHeap(t *testing.T) {
+	s := sort.IntSlice{5, 2, 8}
+	st := NewStat(&s)
+	h := NewHeap(st, func(x interface{}) { s = append(s, x.(int)) },
+		func() interface{} { x := s[len(s)-1]; s = s[:len(s)-1]; return x })
+	heap.Push(h, 1)
+	heap.Push(h, 9)
+	if got := fmt.Sprint(PopAllSorted(h)); got != "[1 2 5 8 9]" {
+		t.Errorf("got %s", got)
+	}
+	if len(s) != 0 || st.N.Less == 0 || len(st.O) != 5 {
+		t.Errorf("left %v after %+v", s, st)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, 10, 100} {
+		for k := 0; k <= n; k += 1 + n/7 {
+			s := make(sort.IntSlice, n)
+			for i := range s {
+				s[i] = r.Intn(n/2 + 1)
+			}
+			want := append(sort.IntSlice(nil), s...)
+			sort.Sort(sort.Reverse(want))
+			top := TopK(s, k)
+			if top.Len() != k || fmt.Sprint(s[:k]) != fmt.Sprint(want[:k]) {
+				t.Errorf("n=%d k=%d: got %v, want %v", n, k, s[:k], want[:k])
+			}
+			sort.Sort(s)
+			sort.Sort(want)
+			if fmt.Sprint(s) != fmt.Sprint(want) {
+				t.Errorf("n=%d k=%d: not a permutation", n, k)
+			}
+		}
+	}
+	s := Letters("qwertyuiop")
+	TopK(NewRev(s), 3)
+	if got := s[:3].String(); got != "eio" {
+		t.Errorf("least 3: got %s", got)
+	}
+	st := NewStat(Letters(bytes.Repeat([]byte("a"), 1000)))
+	selectK(st, 0, 1000, 500)
+	if st.N.Less > 2*1000+10 {
+		t.Errorf("all-equal input took %d comparisons", st.N.Less)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	for _, s := range []string{"", "a", "aaaa", "abc", "aabbbcdddde"} {
+		l := Letters(s)
+		u := Unique(l)
+		want := string(l[:0])
+		for i := range l {
+			if i == 0 || l[i] != l[i-1] {
+				want += string(l[i])
+			}
+		}
+		n := Dedup(l)
+		if n != u || string(l[:n]) != want {
+			t.Errorf("%q: Dedup %d %q, Unique %d, want %q", s, n, l[:n], u, want)
+		}
+	}
+}
+
+func TestRuns(t *testing.T) {
+	tests := map[string]string{
+		"":        "[]",
+		"a":       "[1]",
+		"abcc":    "[4]",
+		"dcba":    "[1 1 1 1]",
+		"abcabza": "[3 3 1]",
+	}
+	for s, want := range tests {
+		if got := fmt.Sprint(Runs(Letters(s))); got != want {
+			t.Errorf("%q: got %s, want %s", s, got, want)
+		}
+	}
+	r := Analyze(io.Discard, false, sort.Sort)
+	for _, v := range r.Results {
+		if v.Name == "Ascending" && v.Runs != 1 || v.Name == "Descending" && v.Runs != v.Len {
+			t.Errorf("%s: %d runs", v.Name, v.Runs)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	s := Letters("dbadcd")
+	for i, want := range []int{3, 1, 0, 3, 2, 3} {
+		if got := Rank(s, i); got != want {
+			t.Errorf("Rank(%d): got %d, want %d", i, got, want)
+		}
+	}
+	if got := Percentile(s, 0); got != 75 {
+		t.Errorf("Percentile(d): got %v, want 75", got)
+	}
+	if got := Percentile(s, 2); got != 100.0/12 {
+		t.Errorf("Percentile(a): got %v", got)
+	}
+}
+
+// keyedStrings orders strings by their bytes, and records a tag per element
+// so that stability can be observed.
+type keyedStrings struct {
+	s   sort.StringSlice
+	tag []int
+}
+
+func (k keyedStrings) Len() int           { return len(k.s) }
+func (k keyedStrings) Less(i, j int) bool { return k.s[i] < k.s[j] }
+func (k keyedStrings) Key(i int) []byte   { return []byte(k.s[i]) }
+func (k keyedStrings) Swap(i, j int) {
+	k.s.Swap(i, j)
+	k.tag[i], k.tag[j] = k.tag[j], k.tag[i]
+}
+
+func TestRadixSort(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, lsd := range []bool{false, true} {
+		for n := 1; n < 200; n += 13 {
+			k := keyedStrings{make(sort.StringSlice, n), NewIntSeq(n)}
+			for i := range k.s {
+				b := make([]byte, r.Intn(4))
+				for j := range b {
+					b[j] = "ab\x00\xff"[r.Intn(4)]
+				}
+				k.s[i] = string(b)
+			}
+			want := keyedStrings{append(sort.StringSlice(nil), k.s...), NewIntSeq(n)}
+			sort.Stable(want)
+			if lsd {
+				RadixSortLSD(k)
+			} else {
+				RadixSort(k)
+			}
+			if !reflect.DeepEqual(k.s, want.s) {
+				t.Errorf("lsd=%v n=%d: got %q", lsd, n, k.s)
+			}
+			if lsd && !reflect.DeepEqual(k.tag, want.tag) {
+				t.Errorf("lsd=%v n=%d: unstable", lsd, n)
+			}
+		}
+	}
+}
+
+func TestBucketSort(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := make(sort.Float64Slice, 500)
+	for i := range s {
+		s[i] = r.Float64()
+	}
+	calls := 0
+	BucketSort(s, func(i int) int { return int(s[i] * 10) }, 10, func(d sort.Interface) {
+		calls++
+		sort.Sort(d)
+	})
+	if !sort.IsSorted(s) || calls != 10 {
+		t.Errorf("sorted=%v after %d inner calls", sort.IsSorted(s), calls)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("out of range bucket did not panic")
+		}
+	}()
+	BucketSort(s, func(int) int { return 10 }, 10, sort.Sort)
+}
+
+type weekdays []time.Weekday
+
+func (w weekdays) Len() int           { return len(w) }
+func (w weekdays) Less(i, j int) bool { return w[i] < w[j] }
+func (w weekdays) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
+func (w weekdays) IntKey(i int) int   { return int(w[i]) }
+
+func TestCountingSort(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	w := make(weekdays, 100)
+	for i := range w {
+		w[i] = time.Weekday(r.Intn(7))
+	}
+	st := NewStat(w)
+	CountingSort(struct {
+		sort.Interface
+		IntKeyer
+	}{st, w}, 6)
+	if !sort.IsSorted(w) || st.N.Less != 0 || st.N.Swap >= int64(len(w)) {
+		t.Errorf("sorted=%v with %+v", sort.IsSorted(w), st.N)
+	}
+}
+
+func TestMergeBuffered(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, buffered := range []bool{false, true} {
+		for trial := 0; trial < 50; trial++ {
+			n := r.Intn(20)
+			mid := r.Intn(n + 1)
+			s := make(Int16Slice, n)
+			for i := range s {
+				s[i] = int16(r.Intn(5))
+			}
+			sort.Sort(s[:mid])
+			sort.Sort(s[mid:])
+			want := append(Int16Slice(nil), s...)
+			sort.Stable(want)
+			var buf sort.Interface
+			if buffered {
+				buf = make(Int16Slice, mid+1)
+			}
+			MergeBuffered(s, buf, 0, mid, n)
+			if fmt.Sprint(s) != fmt.Sprint(want) {
+				t.Errorf("buffered=%v: got %v, want %v", buffered, s, want)
+			}
+		}
+	}
+	var _ MoveInterface = Permutation(nil)
+	var _ MoveInterface = TimeSlice(nil)
+	var _ MoveInterface = Float64Slice{}
+}
+
+func TestSwap3(t *testing.T) {
+	a, b := NewLetterSeq(4), NewLetterSeq(4)
+	Swap3(a, 0, 1, 3)
+	Swap3(NewSub(b, 0, 4), 0, 1, 3)
+	if a.String() != "dacb" || b.String() != "dacb" {
+		t.Errorf("got %s and %s, want dacb", a, b)
+	}
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 50; n++ {
+		p := NewIntSeq(n)
+		ShuffleR(p, r)
+		a, b := NewLetterSeq(n), NewLetterSeq(n)
+		apply(a, p)
+		apply(NewSub(b, 0, n), p)
+		if a.String() != b.String() {
+			t.Errorf("apply with Swap3: got %s, want %s", a, b)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	s := NewLetterSeq(8)
+	Reverse(s)
+	a := NewAudit(s)
+	sort.Sort(a)
+	if err := a.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	// a sort that cheats by writing through the captured slice
+	s[0], s[1] = s[1], s[0]
+	err, ok := a.Verify().(*AuditError)
+	if !ok || err.Index != 0 || err.Lost {
+		t.Errorf("got %v", err)
+	}
+	a = NewAudit(Int32Slice{3, 2, 1})
+	a.Every = true
+	defer func() {
+		if err, ok := recover().(*AuditError); !ok || !err.Lost || err.Calls != 1 {
+			t.Errorf("got %v", err)
+		}
+	}()
+	a.I.(Int32Slice)[1] = 7
+	a.Less(0, 1)
+}
+
+func TestSealed(t *testing.T) {
+	s := NewSealed(NewLetterSeq(4))
+	sort.Sort(s)
+	s.Seal()
+	s.Seal()
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "Swap(0, 1) called from") || strings.Count(msg, "sortutil_test.go:") != 2 {
+			t.Errorf("got %q", msg)
+		}
+	}()
+	s.Swap(0, 1)
+}
+
+func TestBlowup(t *testing.T) {
+	bubble := func(d sort.Interface) {
+		for n := d.Len(); n > 1; n-- {
+			for i := 1; i < n; i++ {
+				if d.Less(i, i-1) {
+					d.Swap(i, i-1)
+				}
+			}
+		}
+	}
+	var b bytes.Buffer
+	r := Analyze(&b, false, bubble, Sizes(200))
+	if r.Failed() || r.Results[0].Warning == "" || !strings.Contains(b.String(), "Warning: 19900 Less calls") {
+		t.Errorf("no warning for bubble sort: %+v", r.Results[0])
+	}
+	r = Analyze(io.Discard, false, sort.Sort, Sizes(200))
+	for _, v := range r.Results {
+		if v.Warning != "" || v.Ratio == 0 {
+			t.Errorf("%s: ratio %.2f, warning %q", v.Name, v.Ratio, v.Warning)
+		}
+	}
+	if r := Analyze(io.Discard, false, bubble, Sizes(200), Blowup(0)); r.Results[0].Warning != "" {
+		t.Errorf("disabled warning reported: %s", r.Results[0].Warning)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	insertion := func(d sort.Interface) {
+		for i := 1; i < d.Len(); i++ {
+			for j := i; j > 0 && d.Less(j, j-1); j-- {
+				d.Swap(j, j-1)
+			}
+		}
+	}
+	bubble := func(d sort.Interface) {
+		for n := d.Len(); n > 1; n-- {
+			for i := 1; i < n; i++ {
+				if d.Less(i, i-1) {
+					d.Swap(i, i-1)
+				}
+			}
+		}
+	}
+	selection := func(d sort.Interface) {
+		for i := 0; i < d.Len()-1; i++ {
+			m := i
+			for j := i + 1; j < d.Len(); j++ {
+				if d.Less(j, m) {
+					m = j
+				}
+			}
+			d.Swap(i, m)
+		}
+	}
+	heapsort := func(d sort.Interface) {
+		h := NewHeap(NewRev(d), nil, nil)
+		heap.Init(h)
+		for n := d.Len() - 1; n > 0; n-- {
+			d.Swap(0, n)
+			heap.Fix(NewHeap(NewRev(NewSub(d, 0, n)), nil, nil), 0)
+		}
+	}
+	var merge func(d sort.Interface)
+	merge = func(d sort.Interface) {
+		if n := d.Len(); n > 1 {
+			merge(NewSub(d, 0, n/2))
+			merge(NewSub(d, n/2, n))
+			symMerge(d, 0, n/2, n)
+		}
+	}
+	tests := []struct {
+		f    func(sort.Interface)
+		want Family
+	}{
+		{insertion, FamilyInsertion},
+		{bubble, FamilyBubble},
+		{selection, FamilySelection},
+		{quicksort, FamilyQuick},
+		{heapsort, FamilyHeap},
+		{merge, FamilyMerge},
+		{func(sort.Interface) {}, FamilyUnknown},
+	}
+	for _, test := range tests {
+		s := NewIntSeq(200)
+		ShuffleR(s, rand.New(rand.NewSource(1)))
+		rec := &Recorder{I: s}
+		test.f(rec)
+		if got := Classify(rec.T); got != test.want {
+			t.Errorf("got %v, want %v", got, test.want)
+		}
+	}
+}
+
+func TestGoStat(t *testing.T) {
+	s := NewIntSeq(4000)
+	Reverse(s)
+	g := NewGoStat(s)
+	(&Chunked{Size: 1000, Workers: 4}).Sort(g, sort.Sort)
+	m := g.Counts()
+	var total int64
+	for _, c := range m {
+		total += c.Less
+	}
+	if len(m) < 2 || total == 0 || !sort.IsSorted(s) {
+		t.Errorf("%d goroutines, %d Less calls:\n%s", len(m), total, g)
+	}
+	if !strings.HasPrefix(g.String(), "goroutine ") {
+		t.Errorf("got %q", g)
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := NewIntSeq(100)
+	c, st := NewCount(s), NewStat(s)
+	Reverse(s)
+	sort.Sort(c)
+	Reverse(s)
+	sort.Sort(st)
+	if c.N != st.N || c.String() != fmt.Sprintf("Calls: %+v", st.N) {
+		t.Errorf("got %+v, want %+v", c.N, st.N)
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	s := NewIntSeq(100000)
+	for i := 0; i < b.N; i++ {
+		sort.Sort(NewCount(s))
+	}
+}
+
+func BenchmarkStat(b *testing.B) {
+	s := NewIntSeq(100000)
+	for i := 0; i < b.N; i++ {
+		sort.Sort(NewStat(s))
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	s := NewSymbolSeq(12, DigitAlphabet)
+	if s.String() != "012345678901" {
+		t.Errorf("got %s", s)
+	}
+	g := NewSymbolSeq(3, GreekAlphabet)
+	Reverse(g)
+	if got := g.Mark(0, 2); got != "γ̲βα̲" {
+		t.Errorf("got %q", got)
+	}
+	var b bytes.Buffer
+	e := NewSymbolSeq(4, EmojiAlphabet)
+	Reverse(e)
+	sort.Sort(&Log{I: e, W: &b})
+	if e.String() != "😀😁😂😃" || !strings.Contains(b.String(), "̲") {
+		t.Errorf("got %s, log:\n%s", e, b.String())
+	}
+}
+
+func TestWindow(t *testing.T) {
+	var b bytes.Buffer
+	s := Letters("dcba")
+	l := &Log{I: s, W: &b}
+	st := NewStat(l)
+	st.Len()
+	Window(st, 1, 3)
+	Window(NewStat(&Log{I: sort.IntSlice{2, 1}, W: &b}), 0, 2)
+	Window(s, 0, 1)
+	want := "(dcba).Len() [4]\n(dCBa).Window(1, 3)\nWindow(0, 2)\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := (ColorMarker{sort.IntSlice{1, 2, 3}}).MarkRange(1, 3); got != "[1 \x1b[7m2\x1b[0m \x1b[7m3\x1b[0m]" {
+		t.Errorf("got %q", got)
+	}
+	if got := NewSymbolSeq(3, DigitAlphabet).MarkRange(0, 2); got != "0̲1̲2" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSeqConstructors(t *testing.T) {
+	tests := []struct {
+		got  sort.IntSlice
+		want string
+	}{
+		{NewSeq(0, 2, 4), "[0 2 4 6]"},
+		{NewSeq(10, -3, 3), "[10 7 4]"},
+		{NewDescSeq(4), "[3 2 1 0]"},
+		{NewDescSeq(0), "[]"},
+		{NewRepeat([]int{1, 2}, 3), "[1 2 1 2 1 2]"},
+		{NewRepeat(nil, 3), "[]"},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprint(test.got); got != test.want {
+			t.Errorf("got %s, want %s", got, test.want)
+		}
+	}
+}
+
+func TestRandLetters(t *testing.T) {
+	a, b := NewRandLetters(40, 5, 3), NewRandLetters(40, 5, 3)
+	if a.String() != b.String() {
+		t.Errorf("not reproducible: %s, %s", a, b)
+	}
+	sort.Sort(a)
+	if u := Unique(a); u != 5 || a[0] != 'a' || a[39] != 'e' {
+		t.Errorf("got %s with %d distinct", a, u)
+	}
+	s := NewRandInts(3, 1000, 1)
+	sort.Sort(s)
+	if u := Unique(s); u != 3 || s[2] < 3 {
+		t.Errorf("got %v", s)
+	}
+}
+
+func TestBars(t *testing.T) {
+	if got := Spark([]int{-7, 0, 7, 7}); got != "▁▄██" {
+		t.Errorf("got %s", got)
+	}
+	if got := Spark([]int{3, 3}); got != "▁▁" {
+		t.Errorf("got %s", got)
+	}
+	b := Bars(NewDescSeq(8))
+	if got := b.Mark(0, 7); got != "\x1b[1;31m█\x1b[0m▇▆▅▄▃▂\x1b[1;32m▁\x1b[0m" {
+		t.Errorf("got %q", got)
+	}
+	sort.Sort(b)
+	if b.String() != "▁▂▃▄▅▆▇█" {
+		t.Errorf("got %s", b)
+	}
+}
+
+func BenchmarkSort(b *testing.B) {
+	Bench(b, sort.Sort, nil, []int{100, 10000})
+}
+
+func BenchmarkStableSawtooth(b *testing.B) {
+	Bench(b, sort.Stable, Sawtooth(16), []int{1000})
+}
+
+func TestCompareOrder(t *testing.T) {
+	tests := []struct {
+		a, b sort.Interface
+		want int
+	}{
+		{Letters("abc"), Letters("abd"), -1},
+		{Letters("abd"), Letters("abc"), 1},
+		{Letters("ab"), Letters("abc"), -1},
+		{Letters("abc"), Letters("abc"), 0},
+		{FoldedStringSlice{"Apple", "b"}, FoldedStringSlice{"apple", "B"}, 0},
+		{Float64Slice{S: []float64{1, math.NaN()}}, Float64Slice{S: []float64{1, 2}}, -1},
+		{PrefixSlice{netip.MustParsePrefix("10.0.0.0/8")}, PrefixSlice{netip.MustParsePrefix("10.0.0.0/16")}, -1},
+	}
+	for _, test := range tests {
+		if got := CompareOrder(test.a, test.b); got != test.want {
+			t.Errorf("CompareOrder(%v, %v) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := EqualOrder(test.a, test.b); got != (test.want == 0) {
+			t.Errorf("EqualOrder(%v, %v) = %v", test.a, test.b, got)
+		}
+	}
+	a := FoldedStringSlice{"b", "A", "a", "B"}
+	b := FoldedStringSlice{"B", "a", "b", "A"}
+	sort.Sort(a)
+	sort.Stable(b)
+	if !EqualOrder(a, b) {
+		t.Errorf("%v and %v not equal in order", a, b)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for unsupported type")
+		}
+	}()
+	CompareOrder(NewSub(Letters("a"), 0, 1), NewSub(Letters("a"), 0, 1))
+}
+
+func TestIsPermutationOf(t *testing.T) {
+	tests := []struct {
+		a, b sort.Interface
+		want bool
+	}{
+		{Letters("banana"), Letters("nabana"), true},
+		{Letters("banana"), Letters("bananb"), false},
+		{Letters("ab"), Letters("abb"), false},
+		{sort.IntSlice{3, 1, 2}, sort.IntSlice{1, 2, 3}, true},
+		{FoldedStringSlice{"A", "b"}, FoldedStringSlice{"B", "a"}, true},
+		{sort.Float64Slice{math.NaN(), 1}, sort.Float64Slice{1, 1}, false},
+	}
+	for _, test := range tests {
+		if got := IsPermutationOf(test.a, test.b); got != test.want {
+			t.Errorf("IsPermutationOf(%v, %v) = %v", test.a, test.b, got)
+		}
+	}
+	if s := Letters("banana"); !IsPermutationOf(s, Letters("nabana")) || s.String() != "banana" {
+		t.Errorf("modified input: %s", s)
+	}
+	r := Analyze(io.Discard, false, func(data sort.Interface) {
+		// duplicate the last element over the first
+		if m, ok := data.(MoveInterface); ok {
+			m.Move(0, data.Len()-1)
+		}
+		sort.Sort(data)
+	})
+	for _, v := range r.Results {
+		if v.Name == "Shuffle" && v.Err != "output is not a permutation of input" {
+			t.Errorf("unexpected result: %+v", v)
+		}
+	}
+}
+
+func TestSpearmanFootrule(t *testing.T) {
+	for _, v := range []struct {
+		a, b     Permutation
+		rho      float64
+		footrule int
+	}{
+		{Permutation{}, Permutation{}, 1, 0},
+		{Permutation{0, 1, 2, 3}, Permutation{0, 1, 2, 3}, 1, 0},
+		{Permutation{0, 1, 2, 3}, Permutation{3, 2, 1, 0}, -1, 8},
+		{Permutation{0, 1, 2, 3}, Permutation{1, 0, 2, 3}, 0.8, 2},
+		{Permutation{2, 0, 1}, Permutation{0, 1, 2}, -0.5, 4},
+	} {
+		if got := Spearman(v.a, v.b); math.Abs(got-v.rho) > 1e-9 {
+			t.Errorf("Spearman(%v, %v) = %v, want %v", v.a, v.b, got, v.rho)
+		}
+		if got := Footrule(v.a, v.b); got != v.footrule {
+			t.Errorf("Footrule(%v, %v) = %d, want %d", v.a, v.b, got, v.footrule)
+		}
+	}
+}
+
+func TestMatchOrder(t *testing.T) {
+	keys := sort.IntSlice{30, 10, 20, 0}
+	vals := Letters("dbca")
+	p := NewPermutation(len(keys))
+	sort.Sort(NewProxy(keys, p))
+	MatchOrder(vals, p)
+	if vals.String() != "abcd" {
+		t.Errorf("got %s, want abcd", vals)
+	}
+	for _, ref := range []Permutation{{0, 1}, {0, 0, 1}, {0, 1, 3}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: no panic", ref)
+				}
+			}()
+			MatchOrder(Letters("abc"), ref)
+		}()
+	}
+}
+
+func TestCycles(t *testing.T) {
+	p := Permutation{2, 1, 3, 0, 5, 4}
+	if got := fmt.Sprint(Cycles(p)); got != "[[0 2 3] [1] [4 5]]" {
+		t.Errorf("got %s", got)
+	}
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 30; n++ {
+		p := Permutation(r.Perm(n))
+		want := NewLetterSeq(n)
+		MatchOrder(want, p)
+		a := NewLetterSeq(n)
+		s := &Stat{I: a}
+		ApplySwaps(s, p)
+		if a.String() != want.String() || s.N.Swap != int64(n-len(Cycles(p))) {
+			t.Errorf("ApplySwaps: got %s in %d swaps, want %s", a, s.N.Swap, want)
+		}
+		b := NewLetterSeq(n)
+		ApplyMoves(b, p)
+		if b.String() != want.String() {
+			t.Errorf("ApplyMoves: got %s, want %s", b, want)
+		}
+		f := Float64Slice{S: make([]float64, n)}
+		for i := range f.S {
+			f.S[i] = float64(i)
+		}
+		ApplyMoves(f, p)
+		if fmt.Sprint(f.S) != fmt.Sprint(p) {
+			t.Errorf("ApplyMoves: got %v, want %v", f.S, p)
+		}
+	}
+}
+
+func TestAdaptivityReport(t *testing.T) {
+	insertion := func(data sort.Interface) {
+		for i := 1; i < data.Len(); i++ {
+			for j := i; j > 0 && data.Less(j, j-1); j-- {
+				data.Swap(j, j-1)
+			}
+		}
+	}
+	a := AdaptivityReport(insertion, 1000)
+	if a.Sorted != 999 || a.SortedRatio > 0.01 || a.NearlyRatio > 0.1 {
+		t.Errorf("insertion sort: %v", a)
+	}
+	selection := func(data sort.Interface) {
+		for i := 0; i < data.Len(); i++ {
+			m := i
+			for j := i + 1; j < data.Len(); j++ {
+				if data.Less(j, m) {
+					m = j
+				}
+			}
+			data.Swap(i, m)
+		}
+	}
+	if a := AdaptivityReport(selection, 1000); a.SortedRatio < 0.99 || a.NearlyRatio < 0.99 {
+		t.Errorf("selection sort: %v", a)
+	}
+	if a := AdaptivityReport(insertion, 0); a.Random != 0 || a.SortedRatio != 0 {
+		t.Errorf("empty: %v", a)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	bad := func(data sort.Interface) { data.Less(0, data.Len()-1) }
+	lines := map[Level]int{}
+	for _, l := range []Level{Silent, Summary, Failures, Verbose, FullTrace} {
+		var b bytes.Buffer
+		r := Analyze(&b, false, bad, Verbosity(l), Sizes(30))
+		if r.Results[0].Trace == "" {
+			t.Errorf("%v: trace not recorded", l)
+		}
+		lines[l] = strings.Count(b.String(), ").Len() [")
+		if l == Silent && b.Len() != 0 {
+			t.Errorf("silent: wrote %q", b.String())
+		}
+		if l == Summary && (!strings.Contains(b.String(), "Calls: ") || strings.Contains(b.String(), "Len()")) {
+			t.Errorf("summary: wrote %q", b.String())
+		}
+	}
+	// Only FullTrace prints data of length 30 inline with each call.
+	if lines[Verbose] != 0 || lines[FullTrace] != len(cases) {
+		t.Errorf("inline Len lines: %v", lines)
+	}
+	var b bytes.Buffer
+	l := &Log{I: NewLetterSeq(3), W: &b, Level: Summary}
+	sort.Sort(l)
+	if b.Len() != 0 || l.Level.String() != "summary" {
+		t.Errorf("Log at %v wrote %q", l.Level, b.String())
+	}
+}
+
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, io.ErrShortWrite
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestLogErrFlush(t *testing.T) {
+	w := &failWriter{n: 2}
+	l := &Log{I: NewLetterSeq(4), W: w}
+	Reverse(l)
+	if l.Err() != io.ErrShortWrite || w.n != 0 {
+		t.Errorf("got error %v", l.Err())
+	}
+	var b bytes.Buffer
+	l = &Log{I: NewLetterSeq(4), W: &b, Buffered: true}
+	Reverse(l)
+	if b.Len() != 0 {
+		t.Errorf("wrote %q before Flush", b.String())
+	}
+	if err := l.Flush(); err != nil || strings.Count(b.String(), "Swap") != 2 {
+		t.Errorf("got %v, %q", err, b.String())
+	}
+	l = &Log{I: NewLetterSeq(4), W: &failWriter{}, Buffered: true}
+	Reverse(l)
+	if l.Err() != nil || l.Flush() != io.ErrShortWrite {
+		t.Errorf("got %v", l.Err())
+	}
+}
+
+func TestStatGrow(t *testing.T) {
+	s := NewStat(Letters("ba"))
+	sort.Sort(s)
+	s.I = Letters("dcba")
+	s.Less(3, 2)
+	s.Swap(3, 2)
+	if len(s.O) != 4 || s.O[3].Less != 1 || s.O[3].Swap != 1 {
+		t.Errorf("got %+v", s.O)
+	}
+	s.I = Letters("edcbaz")
+	if s.Len(); len(s.O) != 6 {
+		t.Errorf("got %d elements after Len", len(s.O))
+	}
+	// more calls per element than there are elements
+	s = NewStat(Letters("ab"))
+	for i := 0; i < 5; i++ {
+		s.Less(0, 1)
+	}
+	if a := s.Aggregate(); a[0].Min != 5 || a[0].Max != 5 || a[1].Max != 0 {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	var b bytes.Buffer
+	data := NewLetterSeq(8)
+	Reverse(data)
+	w := Wrap(data, WithLog(&b), WithStat(), WithBounds(), WithBudget(1000, 0), WithRecorder(), WithAudit(true),
+		WithTimer())
+	sort.Sort(w)
+	if !sort.IsSorted(data) {
+		t.Errorf("not sorted: %s", data)
+	}
+	if w.Timer.I != w.Audit || w.Log.I != w.Timer {
+		t.Error("timer misplaced")
+	}
+	n := w.Stat.N
+	if int64(len(w.Recorder.T)) != n.Len+n.Less+n.Swap || w.Budget.N != 1000-int(n.Less+n.Swap) {
+		t.Errorf("layers disagree: %+v, %d ops recorded, budget %d", n, len(w.Recorder.T), w.Budget.N)
+	}
+	if strings.Count(b.String(), "Swap(") != int(n.Swap) {
+		t.Errorf("log disagrees with %+v:\n%s", n, b.String())
+	}
+	w.Push("scope")
+	w.Less(0, 1)
+	w.Pop()
+	if c := w.Stat.S["scope"]; c == nil || c.Less != 1 || !strings.Contains(b.String(), "scope {") {
+		t.Errorf("scope not forwarded: %+v", w.Stat.S)
+	}
+	func() {
+		defer func() {
+			if e := recover(); e == nil || !strings.Contains(fmt.Sprint(e), "out of range") {
+				t.Errorf("got panic %v", e)
+			}
+		}()
+		w.Less(0, 8)
+	}()
+	if w.Stat.N.Less != n.Less+1 {
+		t.Errorf("out-of-range call counted: %+v", w.Stat.N)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	data := Letters("dcba")
+	var log []string
+	h := NewHooks(data, Hooks{
+		OnLen:      func(n int) { log = append(log, fmt.Sprint("len ", n)) },
+		OnLess:     func(i, j int, r bool) { log = append(log, fmt.Sprint("less ", i, j, r)) },
+		BeforeSwap: func(i, j int) { log = append(log, "swap "+data.String()) },
+		OnSwap:     func(i, j int) { log = append(log, "swapped "+data.String()) },
+	})
+	h.Len()
+	h.Less(0, 1)
+	h.Swap(0, 3)
+	want := "[len 4 less 0 1 false swap dcba swapped acbd]"
+	if got := fmt.Sprint(log); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	sort.Sort(NewHooks(data, Hooks{}))
+	if data.String() != "abcd" {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestFaulty(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	data := NewLetterSeq(26)
+	ShuffleR(data, r)
+	f := NewFaulty(data, 0, r)
+	sort.Sort(f)
+	if !sort.IsSorted(data) || f.Calls == 0 || len(f.Faults) != 0 {
+		t.Errorf("rate 0: %s with %d faults", data, len(f.Faults))
+	}
+	f = NewFaulty(data, 1, r)
+	sort.Sort(f)
+	if !sort.IsSorted(NewRev(data)) || len(f.Faults) != f.Calls {
+		t.Errorf("rate 1: %s with %d of %d faults", data, len(f.Faults), f.Calls)
+	}
+	ShuffleR(data, r)
+	f = NewFaulty(data, 0.1, r)
+	sort.Sort(f)
+	if n := len(f.Faults); n == 0 || n > f.Calls/4 || !sort.IntsAreSorted(f.Faults) || f.Faults[n-1] >= f.Calls {
+		t.Errorf("rate 0.1: %d of %d faults: %v", n, f.Calls, f.Faults)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpKind identifies a sort.Interface method.
+type OpKind uint8
+
+const (
+	OpLen OpKind = iota
+	OpLess
+	OpSwap
+)
+
+var opNames = [...]string{OpLen: "Len", OpLess: "Less", OpSwap: "Swap"}
+
+func (k OpKind) String() string { return opNames[k] }
+
+// Op is a single recorded call to a sort.Interface method. For Len, I is the
+// returned length. For Less and Swap, I and J are the arguments, and R is the
+// result of Less.
+type Op struct {
+	Kind OpKind
+	I, J int
+	R    bool
+}
+
+// String formats o as it appears in a golden file, such as "Less 3 4 true".
+func (o Op) String() string {
+	switch o.Kind {
+	case OpLen:
+		return fmt.Sprint("Len ", o.I)
+	case OpLess:
+		return fmt.Sprint("Less ", o.I, " ", o.J, " ", o.R)
+	}
+	return fmt.Sprint("Swap ", o.I, " ", o.J)
+}
+
+// parseOp parses the output of Op.String.
+func parseOp(s string) (Op, error) {
+	f := strings.Fields(s)
+	var (
+		o    Op
+		n    int
+		errs [3]error
+	)
+	switch {
+	case len(f) == 2 && f[0] == "Len":
+		o.Kind, n = OpLen, 1
+	case len(f) == 4 && f[0] == "Less":
+		o.Kind, n = OpLess, 2
+		o.R, errs[2] = strconv.ParseBool(f[3])
+	case len(f) == 3 && f[0] == "Swap":
+		o.Kind, n = OpSwap, 2
+	default:
+		return o, fmt.Errorf("sortutil: invalid op %q", s)
+	}
+	o.I, errs[0] = strconv.Atoi(f[1])
+	if n == 2 {
+		o.J, errs[1] = strconv.Atoi(f[2])
+	}
+	for _, err := range errs {
+		if err != nil {
+			return o, fmt.Errorf("sortutil: invalid op %q: %v", s, err)
+		}
+	}
+	return o, nil
+}
+
+// Trace is a sequence of recorded calls.
+type Trace []Op
+
+// WriteTo writes t to w, one Op per line.
+func (t Trace) WriteTo(w io.Writer) (int64, error) {
+	b := bufio.NewWriter(w)
+	var n int64
+	for _, o := range t {
+		k, _ := fmt.Fprintln(b, o)
+		n += int64(k)
+	}
+	return n, b.Flush()
+}
+
+// ReadTrace reads a Trace in the format written by Trace.WriteTo.
+func ReadTrace(r io.Reader) (Trace, error) {
+	var t Trace
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+		o, err := parseOp(s.Text())
+		if err != nil {
+			return t, err
+		}
+		t = append(t, o)
+	}
+	return t, s.Err()
+}
+
+// Diff describes the first divergence of t from want, or returns the empty
+// string if they are identical.
+func (t Trace) Diff(want Trace) string {
+	for i := 0; i < len(t) && i < len(want); i++ {
+		if t[i] != want[i] {
+			return fmt.Sprintf("op %d: got %q, want %q", i, t[i], want[i])
+		}
+	}
+	switch {
+	case len(t) > len(want):
+		return fmt.Sprintf("op %d: got %q, want end of trace", len(want), t[len(want)])
+	case len(t) < len(want):
+		return fmt.Sprintf("op %d: got end of trace, want %q", len(t), want[len(t)])
+	}
+	return ""
+}
+
+// Recorder wraps sort.Interface, appending each call to T.
+type Recorder struct {
+	I sort.Interface
+	T Trace
+}
+
+func (r *Recorder) Len() int {
+	n := r.I.Len()
+	r.T = append(r.T, Op{Kind: OpLen, I: n})
+	return n
+}
+
+func (r *Recorder) Less(i, j int) bool {
+	v := r.I.Less(i, j)
+	r.T = append(r.T, Op{OpLess, i, j, v})
+	return v
+}
+
+func (r *Recorder) Swap(i, j int) {
+	r.I.Swap(i, j)
+	r.T = append(r.T, Op{Kind: OpSwap, I: i, J: j})
+}
@@ -0,0 +1,452 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// VerifyError describes an input that a sorting function failed to sort.
+// Output is the state of the input after sorting, and Reason describes the
+// defect, such as "unsorted at index 3" or a recovered panic.
+type VerifyError struct {
+	Input, Output []int
+	Reason        string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("sortutil: input %v sorted to %v: %s", e.Input, e.Output, e.Reason)
+}
+
+// verify runs f on a copy of in, returning the copy, and a *VerifyError if f
+// panics or does not sort it.
+func verify(f func(sort.Interface), in []int) (sort.IntSlice, error) {
+	out := append(sort.IntSlice(nil), in...)
+	err := protect(f, out)
+	if err == "" {
+		err = check(out, nil)
+	}
+	if err != "" {
+		return out, &VerifyError{append([]int(nil), in...), out, err}
+	}
+	return out, nil
+}
+
+// VerifyAll runs f on every permutation of [0,n), for each n from 0 to maxN,
+// and returns a *VerifyError describing the first permutation that f failed
+// to sort, or nil if all were sorted. Since there are n! permutations of
+// length n, maxN should not exceed 10 or so.
+func VerifyAll(f func(sort.Interface), maxN int) error {
+	for n := 0; n <= maxN; n++ {
+		p := NewIntSeq(n)
+		if _, err := verify(f, p); err != nil {
+			return err
+		}
+		// Heap's algorithm, iteratively
+		c := make([]int, n)
+		for i := 0; i < n; {
+			if c[i] >= i {
+				c[i] = 0
+				i++
+				continue
+			}
+			if i%2 == 0 {
+				p.Swap(0, i)
+			} else {
+				p.Swap(c[i], i)
+			}
+			if _, err := verify(f, p); err != nil {
+				return err
+			}
+			c[i]++
+			i = 0
+		}
+	}
+	return nil
+}
+
+// VerifyMultisets runs f on every sequence of length n over the values
+// [0,symbols), for each n from 0 to maxN, and returns a *VerifyError
+// describing the first sequence that f failed to sort, or nil if all were
+// sorted. This covers every arrangement of every multiset of those values,
+// exercising the handling of equal elements that VerifyAll cannot. There are
+// symbols^n sequences of length n. VerifyMultisets panics if symbols < 1.
+func VerifyMultisets(f func(sort.Interface), symbols, maxN int) error {
+	if symbols < 1 {
+		panic("sortutil: VerifyMultisets needs at least one symbol")
+	}
+	for n := 0; n <= maxN; n++ {
+		s := make([]int, n)
+		for {
+			if _, err := verify(f, s); err != nil {
+				return err
+			}
+			// increment s as an n-digit base-symbols counter
+			i := 0
+			for ; i < n && s[i] == symbols-1; i++ {
+				s[i] = 0
+			}
+			if i == n {
+				break
+			}
+			s[i]++
+		}
+	}
+	return nil
+}
+
+// Fuzz adds the datasets run by Analyze to the seed corpus of f, then fuzzes
+// sortFn with inputs decoded as a ByteSlice. Each input is sorted through the
+// Bounds and Budget wrappers, and the fuzz target fails if sortFn panics,
+// exceeds a budget of 2n²+1000 calls, or does not sort the input. Fuzz is
+// intended to be called from a fuzz test:
+//
+//	func FuzzMySort(f *testing.F) { sortutil.Fuzz(f, MySort) }
+func Fuzz(f *testing.F, sortFn func(sort.Interface)) {
+	for _, c := range cases {
+		var b []byte
+		switch v := c.Gen(DefaultLen, rand.New(rand.NewSource(1))).(type) {
+		case Letters:
+			b = v
+		case ByteSlice:
+			b = v
+		case sort.IntSlice:
+			for _, x := range v {
+				b = append(b, byte(x))
+			}
+		default:
+			continue
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, in []byte) {
+		data := append(ByteSlice(nil), in...)
+		n := len(data)
+		err := protect(sortFn, &Bounds{I: &Budget{I: data, N: 2*n*n + 1000}})
+		if err == "" {
+			err = check(data, nil)
+		}
+		if err != "" {
+			t.Fatalf("input %q sorted to %q: %s", in, []byte(data), err)
+		}
+	})
+}
+
+// CheckOpt configures a call to Check.
+type CheckOpt func(*checkConfig)
+
+type checkConfig struct {
+	trials, maxLen int
+	seed           int64
+}
+
+// CheckTrials sets the number of random inputs generated by Check. The
+// default is 1000.
+func CheckTrials(n int) CheckOpt { return func(c *checkConfig) { c.trials = n } }
+
+// CheckMaxLen sets the maximum length of inputs generated by Check. The
+// default is 64.
+func CheckMaxLen(n int) CheckOpt { return func(c *checkConfig) { c.maxLen = n } }
+
+// CheckSeed sets the seed from which Check generates inputs. The default is 1.
+func CheckSeed(seed int64) CheckOpt { return func(c *checkConfig) { c.seed = seed } }
+
+// checkGens are the distributions from which Check draws inputs.
+var checkGens = []Generator{
+	func(n int, r *rand.Rand) sort.Interface { return sort.IntSlice(r.Perm(n)) },
+	RandMod(2),
+	RandMod(4),
+	func(n int, r *rand.Rand) sort.Interface { return RandMod(n/4+1)(n, r) },
+	Sawtooth(3),
+	Sorted(RandMod(8)),
+	Reversed(Sorted(RandMod(8))),
+	Swapped(2),
+	Skewed(4, 0.9),
+}
+
+// Check runs sortFn on random inputs of random lengths drawn from a variety of
+// distributions, verifying that each is sorted and remains a permutation of
+// the original input. On failure, the input is shrunk by repeatedly removing
+// elements and reducing values for as long as sortFn continues to fail, and a
+// *VerifyError describing the minimal input is returned. Check returns nil if
+// every input was sorted.
+func Check(sortFn func(sort.Interface), opts ...CheckOpt) error {
+	c := checkConfig{trials: 1000, maxLen: 64, seed: 1}
+	for _, o := range opts {
+		o(&c)
+	}
+	r := rand.New(rand.NewSource(c.seed))
+	for i := 0; i < c.trials; i++ {
+		g := checkGens[r.Intn(len(checkGens))]
+		in := g(r.Intn(c.maxLen+1), r).(sort.IntSlice)
+		if err := verifyPerm(sortFn, in); err != nil {
+			return shrink(sortFn, in, err)
+		}
+	}
+	return nil
+}
+
+// verifyPerm is like verify, but also requires the output to be a permutation
+// of the input.
+func verifyPerm(f func(sort.Interface), in []int) error {
+	out, err := verify(f, in)
+	if err != nil {
+		return err
+	}
+	if !IsPermutationOf(out, sort.IntSlice(in)) {
+		return &VerifyError{append([]int(nil), in...), out, "output is not a permutation of input"}
+	}
+	return nil
+}
+
+// shrink returns the error for the smallest input derived from in for which
+// f still fails, given that err is the failure for in.
+func shrink(f func(sort.Interface), in []int, err error) error {
+	fails := func(c []int) bool {
+		if e := verifyPerm(f, c); e != nil {
+			in, err = c, e
+			return true
+		}
+		return false
+	}
+	for progress := true; progress; {
+		progress = false
+		// remove chunks, from half the input down to single elements
+		for k := len(in) / 2; k > 0; k /= 2 {
+			for i := 0; i+k <= len(in); {
+				c := append(append([]int(nil), in[:i]...), in[i+k:]...)
+				if fails(c) {
+					progress = true
+				} else {
+					i += k
+				}
+			}
+		}
+		// reduce values toward zero
+		for i := range in {
+			for _, v := range []int{0, in[i] / 2, in[i] - 1} {
+				if v >= 0 && v < in[i] {
+					c := append([]int(nil), in...)
+					c[i] = v
+					if fails(c) {
+						progress = true
+						break
+					}
+				}
+			}
+		}
+	}
+	return err
+}
+
+// StabilityError describes equal keys whose relative order was changed by a
+// sorting function. The elements at output indices Pos-1 and Pos have the
+// same Key, but originated at input indices Orig[0] > Orig[1].
+type StabilityError struct {
+	Input []int
+	Pos   int
+	Key   int
+	Orig  [2]int
+}
+
+func (e *StabilityError) Error() string {
+	return fmt.Sprintf("sortutil: input %v: equal keys %d at output indices %d and %d came from input indices %d and %d",
+		e.Input, e.Key, e.Pos-1, e.Pos, e.Orig[0], e.Orig[1])
+}
+
+// keyed is a sequence of keys tagged with their original indices, ordered by
+// key alone.
+type keyed []struct{ key, idx int }
+
+func (k keyed) Len() int           { return len(k) }
+func (k keyed) Less(i, j int) bool { return k[i].key < k[j].key }
+func (k keyed) Swap(i, j int)      { k[i], k[j] = k[j], k[i] }
+
+// CheckStable runs sortFn on random inputs with many duplicate keys, each
+// tagged with its original index, and returns a *StabilityError describing
+// the first pair of equal keys found out of their original order. If an input
+// is not sorted at all, a *VerifyError is returned instead. CheckStable
+// returns nil if every input was stably sorted.
+func CheckStable(sortFn func(sort.Interface)) error {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n <= 256; n += 1 + n/8 {
+		for _, k := range []int{1, 2, 3, n/4 + 1} {
+			in := make([]int, n)
+			data := make(keyed, n)
+			for i := range data {
+				in[i] = r.Intn(k)
+				data[i].key, data[i].idx = in[i], i
+			}
+			err := protect(sortFn, data)
+			if err == "" {
+				err = check(data, nil)
+			}
+			if err != "" {
+				out := make([]int, n)
+				for i, v := range data {
+					out[i] = v.key
+				}
+				return &VerifyError{in, out, err}
+			}
+			for i := 1; i < n; i++ {
+				a, b := data[i-1], data[i]
+				if a.key == b.key && a.idx > b.idx {
+					return &StabilityError{in, i, a.key, [2]int{a.idx, b.idx}}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// DiffError describes a mismatch between the results of a sorting function
+// and of the standard library. The input may be reproduced by passing Len and
+// a source seeded with Seed to the Generator under test. Index is the first
+// position at which the results differ, or -1 if the function panicked.
+type DiffError struct {
+	Seed   int64
+	Len    int
+	Index  int
+	Reason string
+}
+
+func (e *DiffError) Error() string {
+	return fmt.Sprintf("sortutil: seed %d, len %d: %s", e.Seed, e.Len, e.Reason)
+}
+
+// DiffAgainstStdlib runs trials comparisons of sortFn against sort.Stable.
+// Each trial generates three identical inputs with gen, at a random length
+// of up to 256 and from a source with a random seed. One is sorted by sortFn,
+// another by sort.Stable, and each resulting element is compared using the
+// Less method of the third, unsorted input, through the original indices of
+// the sorted elements. Equal elements are therefore interchangeable, and the
+// comparison is equally valid against sort.Sort. A *DiffError describing the
+// first mismatch is returned, or nil if there was none.
+func DiffAgainstStdlib(sortFn func(sort.Interface), gen Generator, trials int) error {
+	r := rand.New(rand.NewSource(1))
+	for t := 0; t < trials; t++ {
+		seed, n := r.Int63(), r.Intn(257)
+		in := func() sort.Interface { return gen(n, rand.New(rand.NewSource(seed))) }
+		orig, a, b := in(), in(), in()
+		n = orig.Len()
+		pa, pb := NewIntSeq(n), NewIntSeq(n)
+		if err := protect(sortFn, NewProxy(a, pa)); err != "" {
+			return &DiffError{seed, n, -1, err}
+		}
+		sort.Stable(NewProxy(b, pb))
+		for i := 0; i < n; i++ {
+			if x, y := pa[i], pb[i]; orig.Less(x, y) || orig.Less(y, x) {
+				return &DiffError{seed, n, i, fmt.Sprintf("element %d differs from sort.Stable", i)}
+			}
+		}
+	}
+	return nil
+}
+
+// CrossLess is implemented by containers that can compare their elements
+// with those of another container of the same type. CrossLess reports whether
+// element i must sort before element j of other. All built-in containers
+// implement CrossLess.
+type CrossLess interface {
+	CrossLess(i int, other sort.Interface, j int) bool
+}
+
+// crossLess returns a function reporting whether element i of a sorts before
+// element j of b, using CrossLess, or else LessValue on loaded elements. The
+// slice types of package sort are also supported. If a supports none of
+// these, crossLess returns nil.
+func crossLess(a, b sort.Interface) func(i, j int) bool {
+	switch x := a.(type) {
+	case CrossLess:
+		return func(i, j int) bool { return x.CrossLess(i, b, j) }
+	case sort.IntSlice:
+		y := b.(sort.IntSlice)
+		return func(i, j int) bool { return x[i] < y[j] }
+	case sort.StringSlice:
+		y := b.(sort.StringSlice)
+		return func(i, j int) bool { return x[i] < y[j] }
+	case sort.Float64Slice:
+		y := b.(sort.Float64Slice)
+		return func(i, j int) bool { return x[i] < y[j] || math.IsNaN(x[i]) && !math.IsNaN(y[j]) }
+	}
+	m, ok1 := a.(MoveInterface)
+	n, ok2 := b.(MoveInterface)
+	v, ok3 := a.(ValueLesser)
+	if !ok1 || !ok2 || !ok3 {
+		return nil
+	}
+	return func(i, j int) bool { return v.LessValue(m.Load(i), n.Load(j)) }
+}
+
+// mustCrossLess is like crossLess, but panics if a is unsupported.
+func mustCrossLess(a, b sort.Interface) func(i, j int) bool {
+	f := crossLess(a, b)
+	if f == nil {
+		panic(fmt.Sprintf("sortutil: %T does not implement CrossLess", a))
+	}
+	return f
+}
+
+// CompareOrder compares a and b lexicographically, element by element, using
+// only comparisons. It returns -1 if a sorts before b, 1 if b sorts before a,
+// and 0 if every element of each is equivalent to the corresponding element
+// of the other. A container that is a prefix of the other sorts first. a and
+// b must be of the same type, which must implement CrossLess, or both
+// MoveInterface and ValueLesser, or be one of the slice types of package sort.
+func CompareOrder(a, b sort.Interface) int {
+	ab, ba := mustCrossLess(a, b), mustCrossLess(b, a)
+	n, m := a.Len(), b.Len()
+	for i := 0; i < n && i < m; i++ {
+		switch {
+		case ab(i, i):
+			return -1
+		case ba(i, i):
+			return 1
+		}
+	}
+	switch {
+	case n < m:
+		return -1
+	case n > m:
+		return 1
+	}
+	return 0
+}
+
+// EqualOrder reports whether a and b have the same length and equivalent
+// elements at each index, as by CompareOrder. This allows the output of two
+// sorting functions to be compared even when equal elements are
+// distinguishable, or have no equality operator.
+func EqualOrder(a, b sort.Interface) bool {
+	return a.Len() == b.Len() && CompareOrder(a, b) == 0
+}
+
+// IsPermutationOf reports whether a and b hold the same multiset of elements,
+// where elements are equal if neither sorts before the other. Neither is
+// modified; instead, their indices are sorted and compared. a and b must meet
+// the requirements of CompareOrder.
+func IsPermutationOf(a, b sort.Interface) bool {
+	return isPermutation(a, b, mustCrossLess(a, b), mustCrossLess(b, a))
+}
+
+func isPermutation(a, b sort.Interface, ab, ba func(i, j int) bool) bool {
+	n := a.Len()
+	if n != b.Len() {
+		return false
+	}
+	pa, pb := NewIntSeq(n), NewIntSeq(n)
+	sort.Slice(pa, func(i, j int) bool { return a.Less(pa[i], pa[j]) })
+	sort.Slice(pb, func(i, j int) bool { return b.Less(pb[i], pb[j]) })
+	for k := range pa {
+		if ab(pa[k], pb[k]) || ba(pb[k], pa[k]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sortutil
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// Wrapped is a stack of wrappers built by Wrap. The embedded Interface is the
+// outermost layer, to be passed to the sorting function, and each other field
+// holds its layer, or nil if that layer was not requested. The layers are
+// nested from the outside in as follows:
+//
+//	Bounds, Budget, Recorder, Count, Stat, Log, Timer, Audit, data
+//
+// so that out-of-range and over-budget calls are rejected before anything
+// records them, Stat counts what Log prints, Timer excludes the time taken by
+// the layers above it, and Audit sees only the calls that reach the data.
+// Since Audit must wrap the data itself, Timer includes its verification.
+type Wrapped struct {
+	sort.Interface
+	Bounds   *Bounds
+	Budget   *Budget
+	Recorder *Recorder
+	Count    *Count
+	Stat     *Stat
+	Log      *Log
+	Timer    *Timer
+	Audit    *Audit
+
+	deadline time.Duration
+	scope    sort.Interface
+}
+
+// A Wrapper selects a layer of a Wrapped.
+type Wrapper func(*Wrapped)
+
+// WithBounds adds a Bounds layer.
+func WithBounds() Wrapper {
+	return func(w *Wrapped) { w.Bounds = &Bounds{} }
+}
+
+// WithBudget adds a Budget layer allowing ops Less and Swap calls, and a wall
+// time of d from the call to Wrap. A non-positive value imposes no limit.
+func WithBudget(ops int, d time.Duration) Wrapper {
+	return func(w *Wrapped) {
+		w.Budget = &Budget{N: ops}
+		if ops <= 0 {
+			w.Budget.N = int(^uint(0) >> 1)
+		}
+		w.deadline = d
+	}
+}
+
+// WithRecorder adds a Recorder layer.
+func WithRecorder() Wrapper {
+	return func(w *Wrapped) { w.Recorder = &Recorder{} }
+}
+
+// WithCount adds a Count layer.
+func WithCount() Wrapper {
+	return func(w *Wrapped) { w.Count = &Count{} }
+}
+
+// WithStat adds a Stat layer, with per-element counts as by NewStat.
+func WithStat() Wrapper {
+	return func(w *Wrapped) { w.Stat = &Stat{} }
+}
+
+// WithLog adds a Log layer writing to out. Its other fields may be set
+// through the Log field of the result.
+func WithLog(out io.Writer) Wrapper {
+	return func(w *Wrapped) { w.Log = &Log{W: out} }
+}
+
+// WithTimer adds a Timer layer.
+func WithTimer() Wrapper {
+	return func(w *Wrapped) { w.Timer = &Timer{} }
+}
+
+// WithAudit adds an Audit layer, verifying after every call if every is true.
+// The data passed to Wrap must implement Snapshotter.
+func WithAudit(every bool) Wrapper {
+	return func(w *Wrapped) { w.Audit = &Audit{Every: every} }
+}
+
+// Wrap wraps data in the layers selected by opts, in the order documented by
+// Wrapped, regardless of the order of opts.
+func Wrap(data sort.Interface, opts ...Wrapper) *Wrapped {
+	w := new(Wrapped)
+	for _, o := range opts {
+		o(w)
+	}
+	x := data
+	if w.Audit != nil {
+		s, ok := data.(Snapshotted)
+		if !ok {
+			panic("sortutil: WithAudit requires a Snapshotter")
+		}
+		w.Audit.I, w.Audit.want = s, s.Snapshot()
+		x = w.Audit
+	}
+	if w.Timer != nil {
+		w.Timer.I, x = x, w.Timer
+	}
+	if w.Log != nil {
+		w.Log.I, x = x, w.Log
+	}
+	if w.Stat != nil {
+		w.Stat.I, w.Stat.O = x, make([]struct{ Less, Swap int64 }, data.Len())
+		x = w.Stat
+	}
+	w.scope = x
+	if w.Count != nil {
+		w.Count.I, x = x, w.Count
+	}
+	if w.Recorder != nil {
+		w.Recorder.I, x = x, w.Recorder
+	}
+	if w.Budget != nil {
+		w.Budget.I, x = x, w.Budget
+		if w.deadline > 0 {
+			w.Budget.Deadline = time.Now().Add(w.deadline)
+		}
+	}
+	if w.Bounds != nil {
+		w.Bounds.I, x = x, w.Bounds
+	}
+	w.Interface = x
+	return w
+}
+
+// Push forwards to the outermost layer that implements Scoper, since Bounds,
+// Budget, Recorder, and Count do not.
+func (w *Wrapped) Push(label string) {
+	if v, ok := w.scope.(Scoper); ok {
+		v.Push(label)
+	}
+}
+
+// Pop forwards to the outermost layer that implements Scoper.
+func (w *Wrapped) Pop() {
+	if v, ok := w.scope.(Scoper); ok {
+		v.Pop()
+	}
+}
+
+// Window forwards to the outermost layer that implements Windower.
+func (w *Wrapped) Window(lo, hi int) { Window(w.scope, lo, hi) }
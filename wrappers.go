@@ -5,11 +5,21 @@
 package sortutil
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const panicmsg = "bounds out of range"
@@ -32,24 +42,119 @@ func NewStat(data sort.Interface) *Stat {
 	}
 	return &Stat{
 		I: data,
-		O: make([]struct{ Less, Swap int }, l),
+		O: make([]struct{ Less, Swap int64 }, l),
 	}
 }
 
+// Counts holds the number of calls made to each sort.Interface method. The
+// counts are 64-bit so that they cannot overflow on any platform.
+type Counts struct{ Len, Less, Swap int64 }
+
 // Stat wraps sort.Interface, counting the number of Len, Less, and Swap calls.
 // Initialize with `&Stat{I: data}`, or use NewStat to initialize for more
 // comprehensive statistics.
+//
+// Calls made between Push and Pop are additionally counted in S under the
+// label of the innermost scope.
+//
+// If the data grows between calls, O grows with it, so that calls on the new
+// elements are counted. O never shrinks.
 type Stat struct {
-	I sort.Interface
-	N struct{ Len, Less, Swap int }
-	O []struct{ Less, Swap int }
+	I     sort.Interface
+	N     Counts
+	O     []struct{ Less, Swap int64 }
+	S     map[string]*Counts
+	scope []*Counts
+	odd   bool
 }
 
-func (s *Stat) Len() int { s.N.Len++; return s.I.Len() }
+// Scoper is implemented by wrappers that can annotate the call structure of
+// recursive algorithms. Push enters a labeled scope, and Pop leaves the most
+// recently entered scope. Algorithms may test for Scoper and call it as they
+// recurse.
+type Scoper interface {
+	Push(label string)
+	Pop()
+}
+
+// Windower is implemented by wrappers that can annotate the range of indices
+// an algorithm is currently working within, such as the partition of a
+// quicksort or the runs of a merge. See Window.
+type Windower interface {
+	Window(lo, hi int)
+}
+
+// Window reports to data that the caller is now working within [lo,hi), if
+// data implements Windower. Algorithms may call Window unconditionally.
+func Window(data sort.Interface, lo, hi int) {
+	if v, ok := data.(Windower); ok {
+		v.Window(lo, hi)
+	}
+}
+
+// Window forwards to s.I, if it implements Windower.
+func (s *Stat) Window(lo, hi int) { Window(s.I, lo, hi) }
+
+// Push enters a scope named label. If s.I implements Scoper, it is pushed too.
+func (s *Stat) Push(label string) {
+	if s.S == nil {
+		s.S = make(map[string]*Counts)
+	}
+	c := s.S[label]
+	if c == nil {
+		c = new(Counts)
+		s.S[label] = c
+	}
+	s.scope = append(s.scope, c)
+	if v, ok := s.I.(Scoper); ok {
+		v.Push(label)
+	}
+}
+
+// Pop leaves the current scope. If s.I implements Scoper, it is popped too.
+func (s *Stat) Pop() {
+	if len(s.scope) > 0 {
+		s.scope = s.scope[:len(s.scope)-1]
+	}
+	if v, ok := s.I.(Scoper); ok {
+		v.Pop()
+	}
+}
+
+// cur returns the counts for the current scope, or nil if there is none.
+func (s *Stat) cur() *Counts {
+	if n := len(s.scope); n > 0 {
+		return s.scope[n-1]
+	}
+	return nil
+}
+
+func (s *Stat) Len() int {
+	s.N.Len++
+	if c := s.cur(); c != nil {
+		c.Len++
+	}
+	n := s.I.Len()
+	if s.O != nil && n > len(s.O) {
+		s.grow(n)
+	}
+	return n
+}
+
+// grow extends O to at least n elements.
+func (s *Stat) grow(n int) {
+	s.O = append(s.O, make([]struct{ Less, Swap int64 }, n-len(s.O))...)
+}
 
 func (s *Stat) Less(i, j int) bool {
 	s.N.Less++
+	if c := s.cur(); c != nil {
+		c.Less++
+	}
 	if s.O != nil {
+		if k := max(i, j); k >= len(s.O) {
+			s.grow(k + 1)
+		}
 		s.O[i].Less++
 		s.O[j].Less++
 	}
@@ -58,17 +163,36 @@ func (s *Stat) Less(i, j int) bool {
 
 func (s *Stat) Swap(i, j int) {
 	s.N.Swap++
+	if i != j {
+		s.odd = !s.odd
+	}
+	if c := s.cur(); c != nil {
+		c.Swap++
+	}
 	if s.O != nil {
+		if k := max(i, j); k >= len(s.O) {
+			s.grow(k + 1)
+		}
 		s.O[i].Swap++
 		s.O[j].Swap++
 	}
 	s.I.Swap(i, j)
 }
 
+// Parity returns the parity of the number of Swap calls made with distinct
+// indices: 0 if even, or 1 if odd. It should equal the Parity of the resulting
+// rearrangement of the data.
+func (s *Stat) Parity() int {
+	if s.odd {
+		return 1
+	}
+	return 0
+}
+
 // StatAggregate contains a summary of element-wise call statistics.
 // Index zero represents Less, while index one represents Swap.
 type StatAggregate [2]struct {
-	Min, Max  int
+	Min, Max  int64
 	Mean, Std float32
 }
 
@@ -83,8 +207,8 @@ func (s *Stat) Aggregate() StatAggregate {
 	}
 	lMean := float32(s.N.Less) / float32(n)
 	sMean := float32(s.N.Swap) / float32(n)
-	lMin, sMin := n, n
-	lMax, sMax := 0, 0
+	lMin, sMin := s.O[0].Less, s.O[0].Swap
+	lMax, sMax := lMin, sMin
 	for _, v := range s.O {
 		l, s := v.Less, v.Swap
 		std := float32(l) - lMean
@@ -112,12 +236,152 @@ func (s *Stat) Aggregate() StatAggregate {
 }
 
 // String summarizes the statistical results and, if possible, aggregated results.
+// Per-scope call counts follow, ordered by label.
 func (s *Stat) String() string {
+	var str string
 	if s.O == nil {
-		return fmt.Sprintf("Calls: %+v", s.N)
+		str = fmt.Sprintf("Calls: %+v", s.N)
+	} else {
+		a := s.Aggregate()
+		str = fmt.Sprintf("Calls: %+v\nLess:  %+v\nSwap:  %+v", s.N, a[0], a[1])
 	}
-	a := s.Aggregate()
-	return fmt.Sprintf("Calls: %+v\nLess:  %+v\nSwap:  %+v", s.N, a[0], a[1])
+	labels := make([]string, 0, len(s.S))
+	for k := range s.S {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		str += fmt.Sprintf("\nScope %q: %+v", k, *s.S[k])
+	}
+	return str
+}
+
+// Count wraps a sort.Interface, counting calls to each method with minimal
+// overhead. Unlike Stat, it keeps no per-element counts and makes no calls at
+// construction, so it may be used to measure production-sized data.
+type Count struct {
+	I sort.Interface
+	N Counts
+}
+
+// NewCount returns a Count wrapping data.
+func NewCount(data sort.Interface) *Count { return &Count{I: data} }
+
+func (c *Count) Len() int           { c.N.Len++; return c.I.Len() }
+func (c *Count) Less(i, j int) bool { c.N.Less++; return c.I.Less(i, j) }
+func (c *Count) Swap(i, j int)      { c.N.Swap++; c.I.Swap(i, j) }
+func (c *Count) String() string     { return fmt.Sprintf("Calls: %+v", c.N) }
+
+// Timer wraps a sort.Interface, accumulating the wall time spent in its Less
+// and Swap methods, which separates the cost of the container from that of
+// the algorithm calling it.
+type Timer struct {
+	I sort.Interface
+	D struct{ Less, Swap time.Duration }
+}
+
+func (t *Timer) Len() int { return t.I.Len() }
+
+func (t *Timer) Less(i, j int) bool {
+	start := time.Now()
+	r := t.I.Less(i, j)
+	t.D.Less += time.Since(start)
+	return r
+}
+
+func (t *Timer) Swap(i, j int) {
+	start := time.Now()
+	t.I.Swap(i, j)
+	t.D.Swap += time.Since(start)
+}
+
+func (t *Timer) String() string { return fmt.Sprintf("Time: %+v", t.D) }
+
+// Hooks holds optional callbacks for NewHooks. Each Before function is called
+// before the corresponding method of the wrapped data, with its arguments, and
+// each On function is called after, with its arguments and result. Nil
+// callbacks are skipped.
+type Hooks struct {
+	BeforeLen  func()
+	OnLen      func(n int)
+	BeforeLess func(i, j int)
+	OnLess     func(i, j int, less bool)
+	BeforeSwap func(i, j int)
+	OnSwap     func(i, j int)
+}
+
+// NewHooks wraps data, calling the callbacks of h around each method call.
+// This allows custom instrumentation without writing a wrapper type.
+func NewHooks(data sort.Interface, h Hooks) sort.Interface {
+	return hooks{data, h}
+}
+
+type hooks struct {
+	sort.Interface
+	h Hooks
+}
+
+func (x hooks) Len() int {
+	if x.h.BeforeLen != nil {
+		x.h.BeforeLen()
+	}
+	n := x.Interface.Len()
+	if x.h.OnLen != nil {
+		x.h.OnLen(n)
+	}
+	return n
+}
+
+func (x hooks) Less(i, j int) bool {
+	if x.h.BeforeLess != nil {
+		x.h.BeforeLess(i, j)
+	}
+	r := x.Interface.Less(i, j)
+	if x.h.OnLess != nil {
+		x.h.OnLess(i, j, r)
+	}
+	return r
+}
+
+func (x hooks) Swap(i, j int) {
+	if x.h.BeforeSwap != nil {
+		x.h.BeforeSwap(i, j)
+	}
+	x.Interface.Swap(i, j)
+	if x.h.OnSwap != nil {
+		x.h.OnSwap(i, j)
+	}
+}
+
+// Faulty wraps sort.Interface, inverting the result of each Less call with
+// probability Rate, drawing randomness from R. Calls counts the Less calls
+// made, and Faults lists, in order, the zero-based ordinals of those whose
+// results were inverted. Faulty is useful for testing that code built around
+// a sort detects or tolerates an inconsistent comparator.
+type Faulty struct {
+	I      sort.Interface
+	Rate   float64
+	R      *rand.Rand
+	Calls  int
+	Faults []int
+}
+
+// NewFaulty returns a Faulty wrapping data.
+func NewFaulty(data sort.Interface, rate float64, r *rand.Rand) *Faulty {
+	return &Faulty{I: data, Rate: rate, R: r}
+}
+
+func (f *Faulty) Len() int      { return f.I.Len() }
+func (f *Faulty) Swap(i, j int) { f.I.Swap(i, j) }
+
+func (f *Faulty) Less(i, j int) bool {
+	r := f.I.Less(i, j)
+	if f.R.Float64() < f.Rate {
+		f.Faults = append(f.Faults, f.Calls)
+		r = !r
+	}
+	f.Calls++
+	return r
 }
 
 // Mark should produce output with the same visible length that fmt.Sprint
@@ -128,6 +392,90 @@ type Marker interface {
 	Mark(i, j int) string
 }
 
+// RangeMarker is an optional extension of Marker for use with Log.
+// MarkRange should behave like Mark, except that every element in [i,j)
+// should be emphasized, such as the window an algorithm is working within.
+type RangeMarker interface {
+	MarkRange(i, j int) string
+}
+
+// ColorMarker wraps a slice-backed sort.Interface, implementing Marker by
+// surrounding elements i and j with ANSI color escapes rather than altering
+// them. Since escapes are not visible, the visible output is identical to
+// that of fmt.Sprint. Byte-based types with a String method, such as Letters,
+// are highlighted per character; other slices are printed element-wise in the
+// style of fmt.Sprint. Values of any other kind are printed without emphasis.
+type ColorMarker struct{ sort.Interface }
+
+const (
+	colorI     = "\x1b[1;31m"
+	colorJ     = "\x1b[1;32m"
+	colorRange = "\x1b[7m"
+	colorReset = "\x1b[0m"
+)
+
+func (c ColorMarker) String() string { return fmt.Sprint(c.Interface) }
+
+func (c ColorMarker) Mark(i, j int) string {
+	return c.mark(func(k int) string {
+		switch k {
+		case i:
+			return colorI
+		case j:
+			return colorJ
+		}
+		return ""
+	})
+}
+
+// MarkRange behaves like String, except elements in [i,j) are shown in
+// reverse video.
+func (c ColorMarker) MarkRange(i, j int) string {
+	return c.mark(func(k int) string {
+		if i <= k && k < j {
+			return colorRange
+		}
+		return ""
+	})
+}
+
+// mark formats c as described for ColorMarker, preceding each element k with
+// the escape returned by color(k), if any.
+func (c ColorMarker) mark(color func(k int) string) string {
+	v := reflect.ValueOf(c.Interface)
+	if v.Kind() != reflect.Slice {
+		return fmt.Sprint(c.Interface)
+	}
+	_, str := c.Interface.(fmt.Stringer)
+	chars := str && v.Type().Elem().Kind() == reflect.Uint8
+	if str && !chars {
+		return fmt.Sprint(c.Interface)
+	}
+	var b bytes.Buffer
+	if !chars {
+		b.WriteByte('[')
+	}
+	for k, n := 0, v.Len(); k < n; k++ {
+		if k > 0 && !chars {
+			b.WriteByte(' ')
+		}
+		color := color(k)
+		b.WriteString(color)
+		if chars {
+			b.WriteByte(byte(v.Index(k).Uint()))
+		} else {
+			fmt.Fprint(&b, v.Index(k).Interface())
+		}
+		if color != "" {
+			b.WriteString(colorReset)
+		}
+	}
+	if !chars {
+		b.WriteByte(']')
+	}
+	return b.String()
+}
+
 // Log wraps sort.Interface, sending debug messages to the supplied Writer.
 // Less and Swap parameters will be space-padded based on the most recent Len
 // call. Since writes are not synchronized, a serializing writer should be
@@ -136,17 +484,77 @@ type Marker interface {
 //
 // If the sort.Interface value implements Marker, Mark will be called for Less
 // and Swap if Len() returned a small enough value.
+//
+// If Time is true, each line is prefixed with the time elapsed since the first
+// logged call and the delta since the previous call, which helps correlate the
+// trace with latency when Less or Swap do real work.
+//
+// Push and Pop indent subsequent lines by the current scope depth, so that the
+// trace of a recursive algorithm mirrors its call structure.
+//
+// ItemThresh is the maximum Len of a sort.Interface that will be printed
+// inline with log messages. If zero, LOG_ITEM_THRESH is used instead. If
+// negative, inline display of the data will be disabled.
+//
+// Level controls how much is logged: below Verbose, calls are passed through
+// silently, and at FullTrace, the data is printed inline regardless of
+// ItemThresh. The zero Level is Verbose.
+//
+// If Buffered is true, output is buffered until Flush is called, so that
+// logging perturbs the timing of the algorithm less. Once a write to W
+// fails, nothing further is written, and the error is reported by Err.
 type Log struct {
-	I    sort.Interface
-	W    io.Writer
-	n, p int
+	I          sort.Interface
+	W          io.Writer
+	Time       bool
+	ItemThresh int
+	Level      Level
+	Buffered   bool
+	n, p       int
+	depth      int
+	t, u       time.Time
+	b          *bufio.Writer
+	err        error
+}
+
+// Level is the amount of detail written by Log and Analyze. Levels are
+// ordered, and each includes the output of the levels below it.
+type Level int
+
+const (
+	Silent    Level = iota - 3 // nothing is written
+	Summary                    // call statistics of each run, but no calls
+	Failures                   // calls made during failed runs
+	Verbose                    // calls made during every run
+	FullTrace                  // calls, with the data printed for every call
+)
+
+var levelNames = [...]string{"silent", "summary", "failures", "verbose", "full-trace"}
+
+func (l Level) String() string {
+	if l < Silent || l > FullTrace {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l-Silent]
 }
 
-// LOG_ITEM_THRESH is the maximum Len of a sort.Interface that will be printed
-// inline with log messages. If negative, inline display of the data will be
-// disabled.
+// LOG_ITEM_THRESH is the default maximum Len of a sort.Interface that will be
+// printed inline with log messages. It is used by any Log with a zero
+// ItemThresh. If negative, inline display of the data will be disabled.
 var LOG_ITEM_THRESH = 26
 
+// inline reports whether data of length n should be printed with log messages.
+func (l *Log) inline(n int) bool {
+	if l.Level >= FullTrace {
+		return true
+	}
+	t := l.ItemThresh
+	if t == 0 {
+		t = LOG_ITEM_THRESH
+	}
+	return n <= t
+}
+
 func (l *Log) Mark(i, j int) string {
 	if m, ok := l.I.(Marker); ok {
 		return m.Mark(i, j)
@@ -160,39 +568,176 @@ func (l *Log) Len() int {
 	if r > 0 {
 		l.p = len(fmt.Sprint(r - 1))
 	}
-	if r <= LOG_ITEM_THRESH {
-		fmt.Fprint(l.W, "(", l.I, ").Len() [", r, "]\n")
+	if l.inline(r) {
+		l.printf("(%v).Len() [%d]\n", l.I, r)
 	} else {
-		fmt.Fprint(l.W, "Len() [", r, "]\n")
+		l.printf("Len() [%d]\n", r)
 	}
 	return r
 }
 
 func (l *Log) Less(i, j int) bool {
 	r := l.I.Less(i, j)
-	if l.n <= LOG_ITEM_THRESH && l.n > 0 {
-		fmt.Fprintf(l.W, "(%v).Less(%*d, %*d) [%v]\n", l.Mark(i, j), l.p, i, l.p, j, r)
+	if l.inline(l.n) && l.n > 0 {
+		l.printf("(%v).Less(%*d, %*d) [%v]\n", l.Mark(i, j), l.p, i, l.p, j, r)
 	} else {
-		fmt.Fprintf(l.W, "Less(%*d, %*d) [%v]\n", l.p, i, l.p, j, r)
+		l.printf("Less(%*d, %*d) [%v]\n", l.p, i, l.p, j, r)
 	}
 	return r
 }
 
 func (l *Log) Swap(i, j int) {
-	if l.n > LOG_ITEM_THRESH || l.n <= 0 {
+	if !l.inline(l.n) || l.n <= 0 {
 		l.I.Swap(i, j)
-		fmt.Fprintf(l.W, "Swap(%*d, %*d)\n", l.p, i, l.p, j)
+		l.printf("Swap(%*d, %*d)\n", l.p, i, l.p, j)
 		return
 	}
 	v := l.Mark(i, j)
 	l.I.Swap(i, j)
-	fmt.Fprintf(l.W, "(%v).Swap(%*d, %*d) [%v]\n", v, l.p, i, l.p, j, l.Mark(i, j))
+	l.printf("(%v).Swap(%*d, %*d) [%v]\n", v, l.p, i, l.p, j, l.Mark(i, j))
 }
 
 func (l *Log) String() string {
 	return fmt.Sprint(l.I)
 }
 
+// Push logs the start of a scope named label and increases the indentation of
+// subsequent lines. If l.I implements Scoper, it is pushed too.
+func (l *Log) Push(label string) {
+	l.printf("%s {\n", label)
+	l.depth++
+	if v, ok := l.I.(Scoper); ok {
+		v.Push(label)
+	}
+}
+
+// Window logs the window [lo,hi), showing the data with the window emphasized
+// if l.I implements RangeMarker, and forwards it to l.I if it implements
+// Windower.
+func (l *Log) Window(lo, hi int) {
+	if m, ok := l.I.(RangeMarker); ok && l.inline(l.n) && l.n > 0 {
+		l.printf("(%v).Window(%*d, %*d)\n", m.MarkRange(lo, hi), l.p, lo, l.p, hi)
+	} else {
+		l.printf("Window(%*d, %*d)\n", l.p, lo, l.p, hi)
+	}
+	Window(l.I, lo, hi)
+}
+
+// Pop logs the end of the current scope. If l.I implements Scoper, it is
+// popped too.
+func (l *Log) Pop() {
+	if l.depth > 0 {
+		l.depth--
+	}
+	l.printf("}\n")
+	if v, ok := l.I.(Scoper); ok {
+		v.Pop()
+	}
+}
+
+// printf writes a single log line, indented by the scope depth and prefixed
+// with timing information if l.Time is set.
+func (l *Log) printf(format string, a ...interface{}) {
+	if l.Level < Verbose || l.err != nil {
+		return
+	}
+	w := l.W
+	if l.Buffered {
+		if l.b == nil {
+			l.b = bufio.NewWriter(l.W)
+		}
+		w = l.b
+	}
+	var err error
+	if l.Time {
+		now := time.Now()
+		if l.t.IsZero() {
+			l.t, l.u = now, now
+		}
+		_, err = fmt.Fprintf(w, "%12v %13s ", now.Sub(l.t), "+"+now.Sub(l.u).String())
+		l.u = now
+	}
+	if l.depth > 0 && err == nil {
+		_, err = io.WriteString(w, strings.Repeat("  ", l.depth))
+	}
+	if err == nil {
+		_, err = fmt.Fprintf(w, format, a...)
+	}
+	l.err = err
+}
+
+// Flush writes any buffered output to W, returning the first write error
+// encountered by l. W may be replaced after a Flush.
+func (l *Log) Flush() error {
+	if l.b != nil {
+		if err := l.b.Flush(); l.err == nil {
+			l.err = err
+		}
+		l.b = nil
+	}
+	return l.err
+}
+
+// Err returns the first error encountered while writing to W, if any. With
+// Buffered set, errors may not be detected until Flush is called.
+func (l *Log) Err() error { return l.err }
+
+// Budget wraps sort.Interface, limiting the total number of Less and Swap
+// calls to N, and the time at which they may be made to Deadline. Once either
+// limit is exceeded, the offending call panics with ErrBudget or ErrDeadline
+// without being passed on to I. N is decremented by each call. A zero Deadline
+// imposes no time limit. Since the deadline is only checked periodically, an
+// algorithm may slightly overrun it.
+type Budget struct {
+	I        sort.Interface
+	N        int
+	Deadline time.Time
+	k        int
+}
+
+// Panic values raised by Budget.
+var (
+	ErrBudget   = errors.New("sortutil: operation budget exceeded")
+	ErrDeadline = errors.New("sortutil: deadline exceeded")
+)
+
+func (b *Budget) spend() {
+	if b.N <= 0 {
+		panic(ErrBudget)
+	}
+	b.N--
+	if b.k++; b.k&63 == 0 && !b.Deadline.IsZero() && time.Now().After(b.Deadline) {
+		panic(ErrDeadline)
+	}
+}
+
+func (b *Budget) Len() int           { return b.I.Len() }
+func (b *Budget) Less(i, j int) bool { b.spend(); return b.I.Less(i, j) }
+func (b *Budget) Swap(i, j int)      { b.spend(); b.I.Swap(i, j) }
+
+// Bounds wraps sort.Interface, panicking with a descriptive message when Less
+// or Swap is called with an index outside [0,n), where n is the result of the
+// most recent Len call. If Len has not been called, Bounds calls it once.
+type Bounds struct {
+	I sort.Interface
+	n int
+	k bool
+}
+
+func (b *Bounds) Len() int { b.n, b.k = b.I.Len(), true; return b.n }
+
+func (b *Bounds) check(op string, i, j int) {
+	if !b.k {
+		b.n, b.k = b.I.Len(), true
+	}
+	if i < 0 || j < 0 || i >= b.n || j >= b.n {
+		panic(fmt.Sprintf("sortutil: %s(%d, %d) out of range [0,%d)", op, i, j, b.n))
+	}
+}
+
+func (b *Bounds) Less(i, j int) bool { b.check("Less", i, j); return b.I.Less(i, j) }
+func (b *Bounds) Swap(i, j int)      { b.check("Swap", i, j); b.I.Swap(i, j) }
+
 // NewSub opaquely wraps a sub-sequence of the provided sort.Interface.
 // NewSub(s,i,j) is semantically equivalent to s[i:j], though the underlying
 // implementation does not need to use a slice.
@@ -216,6 +761,123 @@ func (s sub) Len() int           { return s.n }
 func (s sub) Less(i, j int) bool { return s.s.Less(s.i+i, s.i+j) }
 func (s sub) Swap(i, j int)      { s.s.Swap(s.i+i, s.i+j) }
 
+// NewStride opaquely wraps every stride-th element of s, beginning with the
+// element at index start, as a contiguous sequence, as is needed for the
+// passes of a Shell sort or for sorting a column of a flattened matrix.
+// NewStride will panic unless 0 <= start <= s.Len() and stride > 0.
+func NewStride(s sort.Interface, start, stride int) sort.Interface {
+	l := s.Len()
+	if start < 0 || start > l || stride <= 0 {
+		panic(panicmsg)
+	}
+	n := (l - start + stride - 1) / stride
+	if v, ok := s.(strided); ok {
+		// collapse strides of strides
+		return strided{v.s, v.i + start*v.k, v.k * stride, n}
+	}
+	return strided{s, start, stride, n}
+}
+
+type strided struct {
+	s       sort.Interface
+	i, k, n int
+}
+
+func (s strided) Len() int           { return s.n }
+func (s strided) Less(i, j int) bool { return s.s.Less(s.i+i*s.k, s.i+j*s.k) }
+func (s strided) Swap(i, j int)      { s.s.Swap(s.i+i*s.k, s.i+j*s.k) }
+
+// NewSelect opaquely wraps the elements of s at the indices in idx, in that
+// order, as a contiguous sequence. Sorting the result reorders only those
+// elements of s, among the positions in idx.
+// NewSelect will panic unless each index in idx is distinct and within
+// [0,s.Len()).
+func NewSelect(s sort.Interface, idx []int) sort.Interface {
+	seen := make([]bool, s.Len())
+	for _, i := range idx {
+		if i < 0 || i >= len(seen) || seen[i] {
+			panic(panicmsg)
+		}
+		seen[i] = true
+	}
+	return sel{s, idx}
+}
+
+type sel struct {
+	s   sort.Interface
+	idx []int
+}
+
+func (s sel) Len() int           { return len(s.idx) }
+func (s sel) Less(i, j int) bool { return s.s.Less(s.idx[i], s.idx[j]) }
+func (s sel) Swap(i, j int)      { s.s.Swap(s.idx[i], s.idx[j]) }
+
+// ValueLesser is implemented by containers that can order values obtained
+// from MoveInterface.Load, independent of where those values are stored.
+// All built-in containers implement ValueLesser.
+type ValueLesser interface {
+	LessValue(a, b interface{}) bool
+}
+
+// NewConcat presents parts as a single sequence, in order. Operations within a
+// part are forwarded to that part. Swaps across parts require both parts to
+// implement MoveInterface, and comparisons across parts require the part
+// holding the lesser index to also implement ValueLesser; NewConcat's result
+// will panic otherwise. Ensuring that parts hold values of the same type is
+// left to the caller.
+func NewConcat(parts ...sort.Interface) sort.Interface {
+	off := make([]int, len(parts)+1)
+	for k, p := range parts {
+		off[k+1] = off[k] + p.Len()
+	}
+	return concat{parts, off}
+}
+
+type concat struct {
+	p   []sort.Interface
+	off []int
+}
+
+// locate translates index i into a part and an index within that part.
+func (c concat) locate(i int) (int, int) {
+	k := sort.SearchInts(c.off, i+1) - 1
+	return k, i - c.off[k]
+}
+
+func (c concat) Len() int { return c.off[len(c.p)] }
+
+func (c concat) Less(i, j int) bool {
+	a, x := c.locate(i)
+	b, y := c.locate(j)
+	if a == b {
+		return c.p[a].Less(x, y)
+	}
+	v, ok1 := c.p[a].(ValueLesser)
+	m, ok2 := c.p[a].(MoveInterface)
+	n, ok3 := c.p[b].(MoveInterface)
+	if !ok1 || !ok2 || !ok3 {
+		panic("sortutil: concat parts do not support comparison across parts")
+	}
+	return v.LessValue(m.Load(x), n.Load(y))
+}
+
+func (c concat) Swap(i, j int) {
+	a, x := c.locate(i)
+	b, y := c.locate(j)
+	if a == b {
+		c.p[a].Swap(x, y)
+		return
+	}
+	m, ok1 := c.p[a].(MoveInterface)
+	n, ok2 := c.p[b].(MoveInterface)
+	if !ok1 || !ok2 {
+		panic("sortutil: concat parts do not support swaps across parts")
+	}
+	v := m.Load(x)
+	m.Store(x, n.Load(y))
+	n.Store(y, v)
+}
+
 // NewRev returns a reverse sorter for any sort.Interface.
 // To quickly reverse a sort.Interface relative to its current order, see Reverse.
 func NewRev(s sort.Interface) sort.Interface {
@@ -248,6 +910,14 @@ type proxy struct {
 
 func (p proxy) Len() int           { return p.c.Len() }
 func (p proxy) Less(i, j int) bool { return p.c.Less(i, j) }
+func (p proxy) String() string     { return fmt.Sprint(p.c) }
+
+func (p proxy) Mark(i, j int) string {
+	if m, ok := p.c.(Marker); ok {
+		return m.Mark(i, j)
+	}
+	return fmt.Sprint(p.c)
+}
 
 func (p proxy) Swap(i, j int) {
 	p.c.Swap(i, j)
@@ -256,58 +926,185 @@ func (p proxy) Swap(i, j int) {
 	}
 }
 
-// Analyze runs preselected datasets through the sorting function f.
-// Any runs that fail to be correctly sorted will be listed first. For each
-// run, if verbose is true or a run fails its Len, Less, and Swap calls will
-// be logged to the provided Writer. In all cases, a summary of call count
-// statistics will be written to the Writer.
-func Analyze(w io.Writer, verbose bool, f func(sort.Interface)) {
-	tests := [][2]string{
-		{"qozxgwajmcnisphfldterkvbuy", "Shuffle"},
-		{"abcdefghijklmnopqrstuvwxyz", "Ascending"},
-		{"zyxwvutsrqponmlkjihgfedcba", "Descending"},
-		{"badcfehgjilknmporqtsvuxwzy", "Pair-Transposition"},
-		{"azcxevgtirkpmnolqjshufwdyb", "Zig-Zag"},
-		{"zaxcvetgripknmlojqhsfudwby", "Desc-Zag-Trans"},
-		{"qogwajmcnisphfldterkvbu", "Shuffle Prime"},
-	}
-	n := len(tests)
-	succ := make([]int, 0, n*2)
-	succ, fail := succ[:0], succ[n:n]
-	var data Letters
-	tlen := 0
-	// Sort failures first
-	for i, v := range tests {
-		data = append(data[:0], v[0]...)
-		title := v[1]
-		if len(title) > tlen {
-			tlen = len(title)
-		}
-		f(data)
-		if sort.IsSorted(data) {
-			succ = append(succ, i)
-		} else {
-			fail = append(fail, i)
+// NewMultiKey orders by primary, falling through to each of secondaries in
+// turn to break ties, like an SQL "ORDER BY a, b, c". As with NewProxy, all
+// swaps are duplicated on every key. NewMultiKey will panic if any item in
+// secondaries has a different Len() than primary.
+func NewMultiKey(primary sort.Interface, secondaries ...sort.Interface) sort.Interface {
+	keys := append([]sort.Interface{primary}, secondaries...)
+	for _, k := range secondaries {
+		if primary.Len() != k.Len() {
+			panic(panicmsg)
 		}
 	}
-	n = len(fail)
-	pad := 4 + 7 + 4
-	banner := strings.Repeat("#", tlen+pad)
-	for i, j := range append(fail, succ...) {
-		v := tests[j]
-		data = append(data[:0], v[0]...)
-		title := v[1]
-		status := "[ OK ]"
-		stat := NewStat(data)
-		switch {
-		case i < n:
-			status = "[FAIL]"
-			fallthrough
-		case verbose:
-			stat.I = &Log{I: data, W: w}
+	return multiKey(keys)
+}
+
+type multiKey []sort.Interface
+
+func (m multiKey) Len() int { return m[0].Len() }
+
+func (m multiKey) Less(i, j int) bool {
+	for _, k := range m {
+		if k.Less(i, j) {
+			return true
+		} else if k.Less(j, i) {
+			return false
 		}
-		fmt.Fprintf(w, "%s\n### %s %-*s ###\n%s\n", banner, status, tlen, title, banner)
-		f(stat)
-		fmt.Fprint(w, "\n", stat, "\n\n")
 	}
+	return false
+}
+
+func (m multiKey) Swap(i, j int) {
+	for _, k := range m {
+		k.Swap(i, j)
+	}
+}
+
+// NullsLast orders data by its own Less, except that elements for which
+// isNull reports true sort after all others. isNull is called with indices
+// into data, so it observes the effect of prior swaps.
+func NullsLast(data sort.Interface, isNull func(i int) bool) sort.Interface {
+	return nulls{data, isNull, false}
+}
+
+// NullsFirst is like NullsLast, except that null elements sort before all
+// others.
+func NullsFirst(data sort.Interface, isNull func(i int) bool) sort.Interface {
+	return nulls{data, isNull, true}
+}
+
+type nulls struct {
+	sort.Interface
+	isNull func(i int) bool
+	first  bool
+}
+
+func (n nulls) Less(i, j int) bool {
+	if a, b := n.isNull(i), n.isNull(j); a || b {
+		return a != b && a == n.first
+	}
+	return n.Interface.Less(i, j)
+}
+
+// Sealed wraps a sort.Interface so that it can be sealed once sorting is
+// believed to be complete. Any later call to Less or Swap panics, naming the
+// caller, which catches goroutines that outlive the sort that started them.
+// Seal may be called concurrently with Less and Swap.
+type Sealed struct {
+	I      sort.Interface
+	sealed atomic.Pointer[string]
+}
+
+// NewSealed returns an unsealed Sealed wrapping data.
+func NewSealed(data sort.Interface) *Sealed { return &Sealed{I: data} }
+
+// Seal causes all subsequent calls to Less and Swap to panic.
+func (s *Sealed) Seal() {
+	at := caller(2)
+	s.sealed.CompareAndSwap(nil, &at)
+}
+
+func (s *Sealed) Len() int { return s.I.Len() }
+
+func (s *Sealed) Less(i, j int) bool {
+	s.check("Less", i, j)
+	return s.I.Less(i, j)
+}
+
+func (s *Sealed) Swap(i, j int) {
+	s.check("Swap", i, j)
+	s.I.Swap(i, j)
+}
+
+func (s *Sealed) check(op string, i, j int) {
+	if at := s.sealed.Load(); at != nil {
+		panic(fmt.Sprintf("sortutil: %s(%d, %d) called from %s after Seal at %s", op, i, j, caller(3), *at))
+	}
+}
+
+// caller returns the file and line of the function skip frames above it.
+func caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// GoStat wraps a sort.Interface, counting calls separately for each goroutine
+// that makes them, so that work imbalance among the workers of a parallel sort
+// can be seen. GoStat is safe for concurrent use, but identifying the calling
+// goroutine is slow, so GoStat is unsuitable for timing.
+type GoStat struct {
+	I  sort.Interface
+	mu sync.Mutex
+	g  map[int64]*Counts
+}
+
+// NewGoStat returns a GoStat wrapping data.
+func NewGoStat(data sort.Interface) *GoStat { return &GoStat{I: data} }
+
+func (s *GoStat) add(op OpKind) {
+	id := goid()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.g == nil {
+		s.g = make(map[int64]*Counts)
+	}
+	c := s.g[id]
+	if c == nil {
+		c = new(Counts)
+		s.g[id] = c
+	}
+	switch op {
+	case OpLen:
+		c.Len++
+	case OpLess:
+		c.Less++
+	case OpSwap:
+		c.Swap++
+	}
+}
+
+func (s *GoStat) Len() int           { s.add(OpLen); return s.I.Len() }
+func (s *GoStat) Less(i, j int) bool { s.add(OpLess); return s.I.Less(i, j) }
+func (s *GoStat) Swap(i, j int)      { s.add(OpSwap); s.I.Swap(i, j) }
+
+// Counts returns a copy of the calls made so far, keyed by goroutine ID.
+func (s *GoStat) Counts() map[int64]Counts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make(map[int64]Counts, len(s.g))
+	for id, c := range s.g {
+		m[id] = *c
+	}
+	return m
+}
+
+// String lists the calls made by each goroutine, in order of goroutine ID.
+func (s *GoStat) String() string {
+	m := s.Counts()
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var b bytes.Buffer
+	for _, id := range ids {
+		fmt.Fprintf(&b, "goroutine %d: %+v\n", id, m[id])
+	}
+	return b.String()
+}
+
+// goid returns the ID of the calling goroutine, as parsed from its stack
+// trace header.
+func goid() int64 {
+	var buf [64]byte
+	s := strings.TrimPrefix(string(buf[:runtime.Stack(buf[:], false)]), "goroutine ")
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseInt(s, 10, 64)
+	return id
 }